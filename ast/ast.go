@@ -5,11 +5,14 @@ import (
 	"github.com/pmatseykanets/monkey/token"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position // The position of the first character of the node.
+	End() token.Position // The position immediately after the node.
 }
 
 type Statement interface {
@@ -33,6 +36,20 @@ func (p *Program) TokenLiteral() string {
 
 	return p.Statements[0].TokenLiteral()
 }
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) == 0 {
+		return token.Position{}
+	}
+
+	return p.Statements[0].Pos()
+}
+func (p *Program) End() token.Position {
+	if len(p.Statements) == 0 {
+		return token.Position{}
+	}
+
+	return p.Statements[len(p.Statements)-1].End()
+}
 
 func (p *Program) String() string {
 	var buf bytes.Buffer
@@ -56,6 +73,15 @@ func (n *Let) statementNode() {}
 func (n *Let) TokenLiteral() string {
 	return n.Token.Literal
 }
+func (n *Let) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *Let) End() token.Position {
+	if n.Value == nil {
+		return n.Name.End()
+	}
+	return n.Value.End()
+}
 func (n *Let) String() string {
 	var buf bytes.Buffer
 
@@ -74,12 +100,22 @@ func (n *Let) String() string {
 type Identifier struct {
 	Token token.Token
 	Value string
+	// Depth is set by the resolver to the number of scopes between
+	// the identifier and the scope binding it. It's not part of
+	// the syntax and Equal ignores it.
+	Depth int
 }
 
 func (n *Identifier) expressionNode() {}
 func (n *Identifier) TokenLiteral() string {
 	return n.Token.Literal
 }
+func (n *Identifier) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *Identifier) End() token.Position {
+	return tokenEnd(n.Token)
+}
 func (n *Identifier) String() string {
 	return n.Value
 }
@@ -96,6 +132,15 @@ func (n *Return) statementNode() {}
 func (n *Return) TokenLiteral() string {
 	return n.Token.Literal
 }
+func (n *Return) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *Return) End() token.Position {
+	if n.Value == nil {
+		return tokenEnd(n.Token)
+	}
+	return n.Value.End()
+}
 func (n *Return) String() string {
 	var buf bytes.Buffer
 
@@ -118,6 +163,15 @@ func (n *BareExpr) statementNode() {}
 func (n *BareExpr) TokenLiteral() string {
 	return n.Token.Literal
 }
+func (n *BareExpr) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *BareExpr) End() token.Position {
+	if n.Value == nil {
+		return tokenEnd(n.Token)
+	}
+	return n.Value.End()
+}
 func (n *BareExpr) String() string {
 	if n.Value == nil {
 		return ""
@@ -136,10 +190,37 @@ func (n *IntegerLiteral) expressionNode() {}
 func (n *IntegerLiteral) TokenLiteral() string {
 	return n.Token.Literal
 }
+func (n *IntegerLiteral) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *IntegerLiteral) End() token.Position {
+	return tokenEnd(n.Token)
+}
 func (n *IntegerLiteral) String() string {
 	return strconv.FormatInt(n.Value, 10)
 }
 
+// FloatLiteral represents a floating point literal.
+// E.g. 3.14;
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (n *FloatLiteral) expressionNode() {}
+func (n *FloatLiteral) TokenLiteral() string {
+	return n.Token.Literal
+}
+func (n *FloatLiteral) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *FloatLiteral) End() token.Position {
+	return tokenEnd(n.Token)
+}
+func (n *FloatLiteral) String() string {
+	return strconv.FormatFloat(n.Value, 'g', -1, 64)
+}
+
 // Prefix represents a prefix expression.
 // E.g.
 // !5
@@ -154,6 +235,12 @@ func (n *Prefix) expressionNode() {}
 func (n *Prefix) TokenLiteral() string {
 	return n.Token.Literal
 }
+func (n *Prefix) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *Prefix) End() token.Position {
+	return n.Right.End()
+}
 func (n *Prefix) String() string {
 	return "(" + n.Operator + n.Right.String() + ")"
 }
@@ -164,6 +251,7 @@ func (n *Prefix) String() string {
 // 5 - 5
 // 5 * 5
 // 5 / 5
+// 5 ** 5
 // 5 > 5
 // 5 < 5
 // 5 == 5
@@ -179,10 +267,49 @@ func (n *Infix) expressionNode() {}
 func (n *Infix) TokenLiteral() string {
 	return n.Token.Literal
 }
+func (n *Infix) Pos() token.Position {
+	return n.Left.Pos()
+}
+func (n *Infix) End() token.Position {
+	return n.Right.End()
+}
 func (n *Infix) String() string {
 	return "(" + n.Left.String() + " " + n.Operator + " " + n.Right.String() + ")"
 }
 
+// Paren represents an expression enclosed in parentheses.
+// E.g. (5 + 5) * 2
+type Paren struct {
+	Token  token.Token // The ( token.
+	Value  Expression
+	Rparen token.Position // The closing ).
+}
+
+func (n *Paren) expressionNode() {}
+func (n *Paren) TokenLiteral() string {
+	return n.Token.Literal
+}
+func (n *Paren) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *Paren) End() token.Position {
+	return after(n.Rparen)
+}
+func (n *Paren) String() string {
+	return n.Value.String()
+}
+
+// Unparen returns the expression with the enclosing parentheses removed.
+func Unparen(exp Expression) Expression {
+	for {
+		paren, ok := exp.(*Paren)
+		if !ok {
+			return exp
+		}
+		exp = paren.Value
+	}
+}
+
 // Boolean represents a boolean literal.
 // E.g.
 // true
@@ -196,6 +323,12 @@ func (n *Boolean) expressionNode() {}
 func (n *Boolean) TokenLiteral() string {
 	return n.Token.Literal
 }
+func (n *Boolean) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *Boolean) End() token.Position {
+	return tokenEnd(n.Token)
+}
 func (n *Boolean) String() string {
 	return strconv.FormatBool(n.Value)
 }
@@ -203,11 +336,13 @@ func (n *Boolean) String() string {
 // If represents a conditional if expression.
 // The else is optional and can be ommited.
 // E.g.
-// if (x < y) {
-// 	return x;
-// } else {
-// 	return y;
-// }
+//
+//	if (x < y) {
+//		return x;
+//	} else {
+//		return y;
+//	}
+//
 // An if expression produces a value.
 // let z = if (x < y) { x } else { y };
 type If struct {
@@ -221,6 +356,15 @@ func (n *If) expressionNode() {}
 func (n *If) TokenLiteral() string {
 	return n.Token.Literal
 }
+func (n *If) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *If) End() token.Position {
+	if n.Alternative != nil {
+		return n.Alternative.End()
+	}
+	return n.Consequence.End()
+}
 func (n *If) String() string {
 	buf := "if" + n.Condition.String() + " " + n.Consequence.String()
 	if n.Alternative == nil {
@@ -233,18 +377,26 @@ func (n *If) String() string {
 // Block represents a block statement consisting of
 // one more statements enslosed in brackets.
 // E.g.
-// {
-// 	a + b;
-// }
+//
+//	{
+//		a + b;
+//	}
 type Block struct {
 	Token      token.Token
 	Statements []Statement
+	Rbrace     token.Position // The closing }.
 }
 
 func (n *Block) statementNode() {}
 func (n *Block) TokenLiteral() string {
 	return n.Token.Literal
 }
+func (n *Block) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *Block) End() token.Position {
+	return after(n.Rbrace)
+}
 func (n *Block) String() string {
 	var buf bytes.Buffer
 
@@ -257,11 +409,13 @@ func (n *Block) String() string {
 
 // Function represents a function literal.
 // E.g.
-// fn(x, y) {
-// 	return x + y;
-// }
+//
+//	fn(x, y) {
+//		return x + y;
+//	}
 type Function struct {
 	Token token.Token
+	Name  string // The name of the let binding if the function is bound to one.
 	Args  []*Identifier
 	Body  *Block
 }
@@ -270,6 +424,12 @@ func (n *Function) expressionNode() {}
 func (n *Function) TokenLiteral() string {
 	return n.Token.Literal
 }
+func (n *Function) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *Function) End() token.Position {
+	return n.Body.End()
+}
 func (n *Function) String() string {
 	args := make([]string, len(n.Args))
 	for i := range n.Args {
@@ -288,12 +448,19 @@ type Call struct {
 	Token    token.Token
 	Function Expression
 	Args     []Expression
+	Rparen   token.Position // The closing ).
 }
 
 func (n *Call) expressionNode() {}
 func (n *Call) TokenLiteral() string {
 	return n.Token.Literal
 }
+func (n *Call) Pos() token.Position {
+	return n.Function.Pos()
+}
+func (n *Call) End() token.Position {
+	return after(n.Rparen)
+}
 func (n *Call) String() string {
 	args := make([]string, len(n.Args))
 	for i := range n.Args {
@@ -302,3 +469,252 @@ func (n *Call) String() string {
 
 	return n.Function.String() + "(" + strings.Join(args, ", ") + ")"
 }
+
+// StringLiteral represents a string literal.
+// E.g. "hello world";
+type StringLiteral struct {
+	Token token.Token
+	Value string
+	Quote token.Position // The closing quote.
+}
+
+func (n *StringLiteral) expressionNode() {}
+func (n *StringLiteral) TokenLiteral() string {
+	return n.Token.Literal
+}
+func (n *StringLiteral) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *StringLiteral) End() token.Position {
+	return after(n.Quote)
+}
+func (n *StringLiteral) String() string {
+	return strconv.Quote(n.Value)
+}
+
+// ArrayLiteral represents an array literal.
+// E.g. [1, 2 * 2, fn(x) { x }];
+type ArrayLiteral struct {
+	Token    token.Token // The [ token.
+	Elements []Expression
+	Rbrack   token.Position // The closing ].
+}
+
+func (n *ArrayLiteral) expressionNode() {}
+func (n *ArrayLiteral) TokenLiteral() string {
+	return n.Token.Literal
+}
+func (n *ArrayLiteral) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *ArrayLiteral) End() token.Position {
+	return after(n.Rbrack)
+}
+func (n *ArrayLiteral) String() string {
+	elements := make([]string, len(n.Elements))
+	for i := range n.Elements {
+		elements[i] = n.Elements[i].String()
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// HashLiteral represents a hash literal.
+// Pairs are kept in the order they appear in the source.
+// E.g. {"one": 1, "two": 1 + 1};
+type HashLiteral struct {
+	Token  token.Token // The { token.
+	Keys   []Expression
+	Values []Expression
+	Rbrace token.Position // The closing }.
+}
+
+func (n *HashLiteral) expressionNode() {}
+func (n *HashLiteral) TokenLiteral() string {
+	return n.Token.Literal
+}
+func (n *HashLiteral) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *HashLiteral) End() token.Position {
+	return after(n.Rbrace)
+}
+func (n *HashLiteral) String() string {
+	pairs := make([]string, len(n.Keys))
+	for i := range n.Keys {
+		pairs[i] = n.Keys[i].String() + ": " + n.Values[i].String()
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// Index represents an index expression.
+// E.g.
+// arr[1]
+// hash["key"]
+type Index struct {
+	Token  token.Token // The [ token.
+	Left   Expression
+	Index  Expression
+	Rbrack token.Position // The closing ].
+}
+
+func (n *Index) expressionNode() {}
+func (n *Index) TokenLiteral() string {
+	return n.Token.Literal
+}
+func (n *Index) Pos() token.Position {
+	return n.Left.Pos()
+}
+func (n *Index) End() token.Position {
+	return after(n.Rbrack)
+}
+func (n *Index) String() string {
+	return "(" + n.Left.String() + "[" + n.Index.String() + "])"
+}
+
+// Assign represents an assignment expression.
+// The target must be an identifier or an index expression.
+// E.g.
+// x = 5
+// x += 1
+// arr[0] = 1
+// hash["key"] = 2
+type Assign struct {
+	Token    token.Token // The assignment operator token.
+	Target   Expression
+	Operator string
+	Value    Expression
+}
+
+func (n *Assign) expressionNode() {}
+func (n *Assign) TokenLiteral() string {
+	return n.Token.Literal
+}
+func (n *Assign) Pos() token.Position {
+	return n.Target.Pos()
+}
+func (n *Assign) End() token.Position {
+	return n.Value.End()
+}
+func (n *Assign) String() string {
+	return "(" + n.Target.String() + " " + n.Operator + " " + n.Value.String() + ")"
+}
+
+// While represents a while loop.
+// E.g.
+//
+//	while (x < 10) {
+//		x += 1;
+//	}
+type While struct {
+	Token     token.Token // The WHILE token.
+	Condition Expression
+	Body      *Block
+}
+
+func (n *While) statementNode() {}
+func (n *While) TokenLiteral() string {
+	return n.Token.Literal
+}
+func (n *While) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *While) End() token.Position {
+	return n.Body.End()
+}
+func (n *While) String() string {
+	return "while" + n.Condition.String() + " " + n.Body.String()
+}
+
+// For represents a for-in loop over the elements of an array,
+// the keys of a hash, the characters of a string
+// or the integers from 0 up to but not including n.
+// E.g.
+//
+//	for (x in [1, 2, 3]) {
+//		sum += x;
+//	}
+type For struct {
+	Token    token.Token // The FOR token.
+	Var      *Identifier
+	Iterable Expression
+	Body     *Block
+}
+
+func (n *For) statementNode() {}
+func (n *For) TokenLiteral() string {
+	return n.Token.Literal
+}
+func (n *For) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *For) End() token.Position {
+	return n.Body.End()
+}
+func (n *For) String() string {
+	return "for(" + n.Var.String() + " in " + n.Iterable.String() + ") " + n.Body.String()
+}
+
+// Break represents a break statement.
+// E.g. break;
+type Break struct {
+	Token token.Token
+}
+
+func (n *Break) statementNode() {}
+func (n *Break) TokenLiteral() string {
+	return n.Token.Literal
+}
+func (n *Break) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *Break) End() token.Position {
+	return tokenEnd(n.Token)
+}
+func (n *Break) String() string {
+	return n.TokenLiteral() + ";"
+}
+
+// Continue represents a continue statement.
+// E.g. continue;
+type Continue struct {
+	Token token.Token
+}
+
+func (n *Continue) statementNode() {}
+func (n *Continue) TokenLiteral() string {
+	return n.Token.Literal
+}
+func (n *Continue) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *Continue) End() token.Position {
+	return tokenEnd(n.Token)
+}
+func (n *Continue) String() string {
+	return n.TokenLiteral() + ";"
+}
+
+// tokenEnd returns the position immediately after the tok.
+func tokenEnd(tok token.Token) token.Position {
+	if !tok.Pos.IsValid() {
+		return token.Position{}
+	}
+
+	return token.Position{
+		Offset: tok.Pos.Offset + len(tok.Literal),
+		Line:   tok.Pos.Line,
+		Column: tok.Pos.Column + utf8.RuneCountInString(tok.Literal),
+	}
+}
+
+// after returns the position immediately after the single character
+// delimiter at pos.
+func after(pos token.Position) token.Position {
+	if !pos.IsValid() {
+		return token.Position{}
+	}
+
+	return token.Position{Offset: pos.Offset + 1, Line: pos.Line, Column: pos.Column + 1}
+}
@@ -0,0 +1,201 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pmatseykanets/monkey/token"
+)
+
+var (
+	positionType   = reflect.TypeOf(token.Position{})
+	identifierType = reflect.TypeOf(Identifier{})
+	bareExprType   = reflect.TypeOf(BareExpr{})
+)
+
+// CompareOption configures Equal and Diff.
+type CompareOption func(*comparer)
+
+// WithPositions makes Equal and Diff compare the source positions
+// of the nodes which are ignored by default.
+func WithPositions() CompareOption {
+	return func(c *comparer) {
+		c.positions = true
+	}
+}
+
+// Equal reports whether the trees rooted at a and b are the same:
+// they consist of the same nodes with the same fields.
+// Parentheses and the types of the tokens are ignored. The literals
+// of the tokens are compared only if the other fields of their nodes
+// are equal, e.g. 1.0 and 1.00, as a different Operator or Value
+// implies a different literal.
+func Equal(a, b Node, opts ...CompareOption) bool {
+	c := newComparer(opts)
+	c.first = true
+	c.compare("", reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem())
+
+	return len(c.diffs) == 0
+}
+
+// Diff returns the differences between the trees rooted at a and b,
+// one per line, or an empty string if they are equal.
+// Each line names the path to the differing field and the values
+// in a and b, e.g.
+//
+//	Statements[2].Value.Right.Operator: "+" != "-"
+func Diff(a, b Node, opts ...CompareOption) string {
+	c := newComparer(opts)
+	c.compare("", reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem())
+
+	return strings.Join(c.diffs, "\n")
+}
+
+type comparer struct {
+	positions bool // Whether to compare positions.
+	first     bool // Whether to stop at the first difference.
+	diffs     []string
+}
+
+func newComparer(opts []CompareOption) *comparer {
+	c := &comparer{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *comparer) report(path string, a, b interface{}) {
+	if path != "" {
+		path += ": "
+	}
+	c.diffs = append(c.diffs, fmt.Sprintf("%s%s != %s", path, a, b))
+}
+
+// compare compares the values a and b of the same type found at the path.
+func (c *comparer) compare(path string, a, b reflect.Value) {
+	if c.first && len(c.diffs) > 0 {
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Interface:
+		// Parentheses only group expressions.
+		a, b = unparen(a), unparen(b)
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				c.report(path, typeName(a), typeName(b))
+			}
+			return
+		}
+		if a.Elem().Type() != b.Elem().Type() {
+			c.report(path, typeName(a), typeName(b))
+			return
+		}
+		c.compare(path, a.Elem(), b.Elem())
+
+	case reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				c.report(path, typeName(a), typeName(b))
+			}
+			return
+		}
+		c.compare(path, a.Elem(), b.Elem())
+
+	case reflect.Struct:
+		if a.Type() == positionType {
+			if c.positions && a.Interface() != b.Interface() {
+				c.report(path, a.Interface(), b.Interface())
+			}
+			return
+		}
+		for i := 0; i < a.NumField(); i++ {
+			name := a.Type().Field(i).Name
+			if a.Type() == identifierType && name == "Depth" {
+				// Set by the resolver.
+				continue
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			if a.Field(i).Type() == tokenType {
+				c.compareToken(name, a.Field(i), b.Field(i), a.Type() != bareExprType && scalarsEqual(a, b))
+				continue
+			}
+			c.compare(name, a.Field(i), b.Field(i))
+		}
+
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			c.report(path, fmt.Sprintf("len %d", a.Len()), fmt.Sprintf("len %d", b.Len()))
+		}
+		for i := 0; i < a.Len() && i < b.Len(); i++ {
+			c.compare(fmt.Sprintf("%s[%d]", path, i), a.Index(i), b.Index(i))
+		}
+
+	case reflect.String:
+		if a.String() != b.String() {
+			c.report(path, fmt.Sprintf("%q", a.String()), fmt.Sprintf("%q", b.String()))
+		}
+
+	default:
+		if a.Interface() != b.Interface() {
+			c.report(path, fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+		}
+	}
+}
+
+// compareToken compares the positions of the tokens a and b found
+// at the path and their literals if literal is true.
+func (c *comparer) compareToken(path string, a, b reflect.Value, literal bool) {
+	if literal {
+		c.compare(path+".Literal", a.FieldByName("Literal"), b.FieldByName("Literal"))
+	}
+	c.compare(path+".Pos", a.FieldByName("Pos"), b.FieldByName("Pos"))
+}
+
+// scalarsEqual reports whether the string, number and boolean fields
+// of the nodes a and b, other than the ones set by the resolver,
+// are equal.
+func scalarsEqual(a, b reflect.Value) bool {
+	for i := 0; i < a.NumField(); i++ {
+		if a.Type() == identifierType && a.Type().Field(i).Name == "Depth" {
+			continue
+		}
+		switch a.Field(i).Kind() {
+		case reflect.String, reflect.Int, reflect.Int64, reflect.Float64, reflect.Bool:
+			if a.Field(i).Interface() != b.Field(i).Interface() {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// unparen returns the interface value v with the parentheses
+// enclosing the expression it holds removed.
+func unparen(v reflect.Value) reflect.Value {
+	exp, ok := v.Interface().(*Paren)
+	if !ok {
+		return v
+	}
+
+	node := Node(Unparen(exp))
+	return reflect.ValueOf(&node).Elem()
+}
+
+// typeName returns the type of the node held by v or nil.
+func typeName(v reflect.Value) string {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() || v.IsNil() {
+		return "nil"
+	}
+
+	return v.Type().String()
+}
@@ -0,0 +1,97 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/pmatseykanets/monkey/ast"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"let x = 1 + 2;", "let x = 1 + 2;", ""},
+		{"let x = 1 + 2;", "let  x =\n  1+2", ""},
+		{"let x = (1 + 2);", "let x = 1 + 2;", ""},
+		{
+			"let x = 1 + 2;",
+			"let x = 1 - 2;",
+			"Statements[0].Value.Operator: \"+\" != \"-\"",
+		},
+		{
+			"a; b; c * (d + e);",
+			"a; b; c * (d - e);",
+			"Statements[2].Value.Right.Operator: \"+\" != \"-\"",
+		},
+		{"1", "1.0", "Statements[0].Value: *ast.IntegerLiteral != *ast.FloatLiteral"},
+		{"1", "2", "Statements[0].Value.Value: 1 != 2"},
+		{"1.0", "1.00", "Statements[0].Value.Token.Literal: \"1.0\" != \"1.00\""},
+		{"let x = 007;", "let x = 7;", "Statements[0].Value.Token.Literal: \"007\" != \"7\""},
+		{"[1, 007]", "[1, 7]", "Statements[0].Value.Elements[1].Token.Literal: \"007\" != \"7\""},
+		{"(007)", "7", "Statements[0].Value.Token.Literal: \"007\" != \"7\""},
+		{"x += 1", "x -= 1", "Statements[0].Value.Operator: \"+=\" != \"-=\""},
+		{"!true", "-true", "Statements[0].Value.Operator: \"!\" != \"-\""},
+		{"\"a\"", "\"b\"", "Statements[0].Value.Value: \"a\" != \"b\""},
+		{"if (x) { 1 }", "if (x) { 1 } else { 2 }", "Statements[0].Value.Alternative: nil != *ast.Block"},
+		{"f(a, b)", "f(a)", "Statements[0].Value.Args: len 2 != len 1"},
+		{"a; b", "a", "Statements: len 2 != len 1"},
+		{"let f = fn() {}", "let g = fn() {}", "Statements[0].Name.Value: \"f\" != \"g\"\n" +
+			"Statements[0].Value.Name: \"f\" != \"g\""},
+	}
+
+	for _, tt := range tests {
+		a, b := parse(t, tt.a), parse(t, tt.b)
+		if got := ast.Diff(a, b); tt.want != got {
+			t.Errorf("%q vs %q: expected diff\n%s\ngot\n%s", tt.a, tt.b, tt.want, got)
+		}
+		if want, got := tt.want == "", ast.Equal(a, b); want != got {
+			t.Errorf("%q vs %q: expected Equal %t got %t", tt.a, tt.b, want, got)
+		}
+	}
+}
+
+func TestDiffWithPositions(t *testing.T) {
+	a, b := parse(t, "x + 1"), parse(t, "x+1")
+
+	if !ast.Equal(a, b) {
+		t.Errorf("Expected trees to be equal ignoring positions")
+	}
+	if ast.Equal(a, b, ast.WithPositions()) {
+		t.Errorf("Expected trees to differ in positions")
+	}
+
+	want := "Statements[0].Value.Token.Pos: 1:3 != 1:2\n" +
+		"Statements[0].Value.Right.Token.Pos: 1:5 != 1:3"
+	if got := ast.Diff(a, b, ast.WithPositions()); want != got {
+		t.Errorf("Expected diff\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestDiffNil(t *testing.T) {
+	prg := parse(t, "x")
+
+	if ast.Equal(prg, nil) {
+		t.Errorf("Expected a program not to equal nil")
+	}
+	if !ast.Equal(nil, nil) {
+		t.Errorf("Expected nil to equal nil")
+	}
+	if want, got := "*ast.Program != nil", ast.Diff(prg, nil); want != got {
+		t.Errorf("Expected diff %q got %q", want, got)
+	}
+}
+
+func TestEqualIgnoresDepth(t *testing.T) {
+	a, b := parse(t, "fn() { x }"), parse(t, "fn() { x }")
+	ast.Inspect(a, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Identifier); ok {
+			ident.Depth = 1
+		}
+		return true
+	})
+
+	if !ast.Equal(a, b) {
+		t.Errorf("Expected trees to be equal ignoring depths, diff\n%s", ast.Diff(a, b))
+	}
+}
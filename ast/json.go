@@ -0,0 +1,590 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/pmatseykanets/monkey/token"
+)
+
+// The JSON encoding represents each node as an object with the "kind"
+// member set to the name of the node type, e.g. "Infix", followed by
+// the "pos" and "end" positions of the node and its fields named
+// after the fields of the node type, e.g.
+//
+//	{"kind": "Prefix", "pos": {...}, "end": {...}, "operator": "-", "right": {...}}
+//
+// Positions are objects with the "offset", "line" and "column"
+// members or null if unknown. Missing nodes, such as the alternative
+// of an if expression without else, are null.
+
+// EncodeJSON returns the JSON encoding of the node.
+func EncodeJSON(node Node) ([]byte, error) {
+	return json.Marshal(encodeNode(node))
+}
+
+// DecodeJSON reconstructs a node from its JSON encoding.
+// The encoding of a Program is decoded into a *Program.
+// Malformed encodings, including the ones missing children
+// every node of the kind has, e.g. the operands of an Infix,
+// are reported as errors.
+func DecodeJSON(data []byte) (Node, error) {
+	node, err := decodeNode(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode ast: %v", err)
+	}
+	if node == nil {
+		return nil, fmt.Errorf("decode ast: null node")
+	}
+
+	return node, nil
+}
+
+// field is a member of a JSON object.
+type field struct {
+	name  string
+	value interface{}
+}
+
+// object is a JSON object keeping the order of its members.
+type object []field
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(strconv.Quote(f.name))
+		buf.WriteByte(':')
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+type jsonPosition struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func encodePosition(pos token.Position) *jsonPosition {
+	if !pos.IsValid() {
+		return nil
+	}
+
+	return &jsonPosition{Offset: pos.Offset, Line: pos.Line, Column: pos.Column}
+}
+
+func encodeNode(node Node) interface{} {
+	if v := reflect.ValueOf(node); !v.IsValid() || v.IsNil() {
+		return nil
+	}
+
+	o := object{
+		{"kind", reflect.TypeOf(node).Elem().Name()},
+		{"pos", encodePosition(node.Pos())},
+		{"end", encodePosition(node.End())},
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		o = append(o, field{"statements", encodeStatements(n.Statements)})
+	case *Let:
+		o = append(o, field{"name", encodeNode(n.Name)}, field{"value", encodeNode(n.Value)})
+	case *Return:
+		o = append(o, field{"value", encodeNode(n.Value)})
+	case *BareExpr:
+		o = append(o, field{"value", encodeNode(n.Value)})
+	case *Block:
+		o = append(o, field{"statements", encodeStatements(n.Statements)})
+	case *While:
+		o = append(o, field{"condition", encodeNode(n.Condition)}, field{"body", encodeNode(n.Body)})
+	case *For:
+		o = append(o,
+			field{"var", encodeNode(n.Var)},
+			field{"iterable", encodeNode(n.Iterable)},
+			field{"body", encodeNode(n.Body)},
+		)
+	case *Break, *Continue:
+		// Nothing to do.
+	case *Identifier:
+		o = append(o, field{"value", n.Value})
+	case *IntegerLiteral:
+		o = append(o, field{"literal", n.Token.Literal}, field{"value", n.Value})
+	case *FloatLiteral:
+		o = append(o, field{"literal", n.Token.Literal}, field{"value", n.Value})
+	case *Boolean:
+		o = append(o, field{"value", n.Value})
+	case *StringLiteral:
+		o = append(o, field{"value", n.Value})
+	case *Prefix:
+		o = append(o, field{"operator", n.Operator}, field{"right", encodeNode(n.Right)})
+	case *Infix:
+		o = append(o,
+			field{"operator", n.Operator},
+			field{"opPos", encodePosition(n.Token.Pos)},
+			field{"left", encodeNode(n.Left)},
+			field{"right", encodeNode(n.Right)},
+		)
+	case *Paren:
+		o = append(o, field{"value", encodeNode(n.Value)})
+	case *If:
+		o = append(o,
+			field{"condition", encodeNode(n.Condition)},
+			field{"consequence", encodeNode(n.Consequence)},
+			field{"alternative", encodeNode(n.Alternative)},
+		)
+	case *Function:
+		args := make([]interface{}, len(n.Args))
+		for i, arg := range n.Args {
+			args[i] = encodeNode(arg)
+		}
+		o = append(o, field{"name", n.Name}, field{"args", args}, field{"body", encodeNode(n.Body)})
+	case *Call:
+		o = append(o,
+			field{"lparen", encodePosition(n.Token.Pos)},
+			field{"function", encodeNode(n.Function)},
+			field{"args", encodeExpressions(n.Args)},
+		)
+	case *ArrayLiteral:
+		o = append(o, field{"elements", encodeExpressions(n.Elements)})
+	case *HashLiteral:
+		o = append(o, field{"keys", encodeExpressions(n.Keys)}, field{"values", encodeExpressions(n.Values)})
+	case *Index:
+		o = append(o,
+			field{"lbrack", encodePosition(n.Token.Pos)},
+			field{"left", encodeNode(n.Left)},
+			field{"index", encodeNode(n.Index)},
+		)
+	case *Assign:
+		o = append(o,
+			field{"operator", n.Operator},
+			field{"opPos", encodePosition(n.Token.Pos)},
+			field{"target", encodeNode(n.Target)},
+			field{"value", encodeNode(n.Value)},
+		)
+	default:
+		panic(fmt.Sprintf("ast.EncodeJSON: unexpected node type %T", n))
+	}
+
+	return o
+}
+
+func encodeStatements(list []Statement) []interface{} {
+	nodes := make([]interface{}, len(list))
+	for i, stmt := range list {
+		nodes[i] = encodeNode(stmt)
+	}
+
+	return nodes
+}
+
+func encodeExpressions(list []Expression) []interface{} {
+	nodes := make([]interface{}, len(list))
+	for i, exp := range list {
+		nodes[i] = encodeNode(exp)
+	}
+
+	return nodes
+}
+
+// jsonObject is a decoded JSON object of a node.
+// Its methods decode the members of the object remembering
+// the first error in err.
+type jsonObject struct {
+	kind    string
+	members map[string]json.RawMessage
+	err     error
+}
+
+func (o *jsonObject) fail(name string, err error) {
+	if o.err == nil {
+		o.err = fmt.Errorf("%s.%s: %v", o.kind, name, err)
+	}
+}
+
+// require fails unless the members with the names are present
+// and not null. It's used for the children every node of the kind has.
+func (o *jsonObject) require(names ...string) {
+	for _, name := range names {
+		if raw, ok := o.members[name]; !ok || isNull(raw) {
+			o.fail(name, fmt.Errorf("missing node"))
+		}
+	}
+}
+
+func (o *jsonObject) decode(name string, v interface{}) {
+	raw, ok := o.members[name]
+	if !ok {
+		return
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		o.fail(name, err)
+	}
+}
+
+func (o *jsonObject) position(name string) token.Position {
+	var pos *jsonPosition
+	o.decode(name, &pos)
+	if pos == nil {
+		return token.Position{}
+	}
+
+	return token.Position{Offset: pos.Offset, Line: pos.Line, Column: pos.Column}
+}
+
+func (o *jsonObject) str(name string) string {
+	var s string
+	o.decode(name, &s)
+
+	return s
+}
+
+func (o *jsonObject) node(name string) Node {
+	raw, ok := o.members[name]
+	if !ok {
+		return nil
+	}
+	node, err := decodeNode(raw)
+	if err != nil && o.err == nil {
+		o.err = err
+	}
+
+	return node
+}
+
+func (o *jsonObject) nodes(name string) []Node {
+	var list []json.RawMessage
+	o.decode(name, &list)
+
+	nodes := make([]Node, len(list))
+	for i, raw := range list {
+		node, err := decodeNode(raw)
+		if err != nil && o.err == nil {
+			o.err = err
+		}
+		if node == nil && err == nil {
+			o.fail(name, fmt.Errorf("null node at index %d", i))
+		}
+		nodes[i] = node
+	}
+
+	return nodes
+}
+
+func (o *jsonObject) expression(name string) Expression {
+	node := o.node(name)
+	if node == nil {
+		return nil
+	}
+	exp, ok := node.(Expression)
+	if !ok {
+		o.fail(name, fmt.Errorf("expected an expression got %T", node))
+	}
+
+	return exp
+}
+
+func (o *jsonObject) expressions(name string) []Expression {
+	nodes := o.nodes(name)
+	list := make([]Expression, len(nodes))
+	for i, node := range nodes {
+		exp, ok := node.(Expression)
+		if !ok {
+			o.fail(name, fmt.Errorf("expected an expression got %T", node))
+		}
+		list[i] = exp
+	}
+
+	return list
+}
+
+func (o *jsonObject) statements(name string) []Statement {
+	nodes := o.nodes(name)
+	list := make([]Statement, len(nodes))
+	for i, node := range nodes {
+		stmt, ok := node.(Statement)
+		if !ok {
+			o.fail(name, fmt.Errorf("expected a statement got %T", node))
+		}
+		list[i] = stmt
+	}
+
+	return list
+}
+
+func (o *jsonObject) block(name string) *Block {
+	node := o.node(name)
+	if node == nil {
+		return nil
+	}
+	block, ok := node.(*Block)
+	if !ok {
+		o.fail(name, fmt.Errorf("expected *ast.Block got %T", node))
+	}
+
+	return block
+}
+
+func (o *jsonObject) identifier(name string) *Identifier {
+	node := o.node(name)
+	if node == nil {
+		return nil
+	}
+	ident, ok := node.(*Identifier)
+	if !ok {
+		o.fail(name, fmt.Errorf("expected *ast.Identifier got %T", node))
+	}
+
+	return ident
+}
+
+// requiredMembers lists the members of the node kinds
+// holding children which can't be missing.
+var requiredMembers = map[string][]string{
+	"Let":      {"name", "value"},
+	"While":    {"condition", "body"},
+	"For":      {"var", "iterable", "body"},
+	"Prefix":   {"right"},
+	"Infix":    {"left", "right"},
+	"Paren":    {"value"},
+	"If":       {"condition", "consequence"},
+	"Function": {"body"},
+	"Call":     {"function"},
+	"Index":    {"left", "index"},
+	"Assign":   {"target", "value"},
+}
+
+// isNull reports whether the JSON value is null.
+func isNull(data []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
+
+func decodeNode(data []byte) (Node, error) {
+	if isNull(data) {
+		return nil, nil
+	}
+
+	o := &jsonObject{}
+	if err := json.Unmarshal(data, &o.members); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(o.members["kind"], &o.kind); err != nil {
+		return nil, fmt.Errorf("missing node kind")
+	}
+
+	pos := o.position("pos")
+	end := o.position("end")
+	// keyword returns the token of the keyword starting the node.
+	keyword := func(literal string) token.Token {
+		return token.Token{Type: token.IdentType(literal), Literal: literal, Pos: pos}
+	}
+
+	o.require(requiredMembers[o.kind]...)
+
+	var node Node
+	switch o.kind {
+	case "Program":
+		node = &Program{Statements: o.statements("statements")}
+	case "Let":
+		node = &Let{Token: keyword("let"), Name: o.identifier("name"), Value: o.expression("value")}
+	case "Return":
+		node = &Return{Token: keyword("return"), Value: o.expression("value")}
+	case "BareExpr":
+		n := &BareExpr{Value: o.expression("value")}
+		if n.Value != nil {
+			n.Token = firstToken(n.Value)
+		}
+		node = n
+	case "Block":
+		node = &Block{
+			Token:      token.Token{Type: token.LBRACE, Literal: "{", Pos: pos},
+			Statements: o.statements("statements"),
+			Rbrace:     before(end),
+		}
+	case "While":
+		node = &While{Token: keyword("while"), Condition: o.expression("condition"), Body: o.block("body")}
+	case "For":
+		node = &For{
+			Token:    keyword("for"),
+			Var:      o.identifier("var"),
+			Iterable: o.expression("iterable"),
+			Body:     o.block("body"),
+		}
+	case "Break":
+		node = &Break{Token: keyword("break")}
+	case "Continue":
+		node = &Continue{Token: keyword("continue")}
+	case "Identifier":
+		value := o.str("value")
+		node = &Identifier{Token: token.Token{Type: token.IDENT, Literal: value, Pos: pos}, Value: value}
+	case "IntegerLiteral":
+		n := &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: o.str("literal"), Pos: pos}}
+		o.decode("value", &n.Value)
+		node = n
+	case "FloatLiteral":
+		n := &FloatLiteral{Token: token.Token{Type: token.FLOAT, Literal: o.str("literal"), Pos: pos}}
+		o.decode("value", &n.Value)
+		node = n
+	case "Boolean":
+		n := &Boolean{}
+		o.decode("value", &n.Value)
+		n.Token = keyword(strconv.FormatBool(n.Value))
+		node = n
+	case "StringLiteral":
+		value := o.str("value")
+		node = &StringLiteral{
+			Token: token.Token{Type: token.STRING, Literal: value, Pos: pos},
+			Value: value,
+			Quote: before(end),
+		}
+	case "Prefix":
+		op := o.str("operator")
+		node = &Prefix{
+			Token:    token.Token{Type: token.TokenType(op), Literal: op, Pos: pos},
+			Operator: op,
+			Right:    o.expression("right"),
+		}
+	case "Infix":
+		op := o.str("operator")
+		node = &Infix{
+			Token:    token.Token{Type: token.TokenType(op), Literal: op, Pos: o.position("opPos")},
+			Left:     o.expression("left"),
+			Operator: op,
+			Right:    o.expression("right"),
+		}
+	case "Paren":
+		node = &Paren{
+			Token:  token.Token{Type: token.LPAREN, Literal: "(", Pos: pos},
+			Value:  o.expression("value"),
+			Rparen: before(end),
+		}
+	case "If":
+		node = &If{
+			Token:       keyword("if"),
+			Condition:   o.expression("condition"),
+			Consequence: o.block("consequence"),
+			Alternative: o.block("alternative"),
+		}
+	case "Function":
+		n := &Function{Token: keyword("fn"), Name: o.str("name"), Body: o.block("body")}
+		for _, arg := range o.nodes("args") {
+			ident, ok := arg.(*Identifier)
+			if !ok {
+				o.fail("args", fmt.Errorf("expected *ast.Identifier got %T", arg))
+			}
+			n.Args = append(n.Args, ident)
+		}
+		if n.Args == nil {
+			n.Args = []*Identifier{}
+		}
+		node = n
+	case "Call":
+		node = &Call{
+			Token:    token.Token{Type: token.LPAREN, Literal: "(", Pos: o.position("lparen")},
+			Function: o.expression("function"),
+			Args:     o.expressions("args"),
+			Rparen:   before(end),
+		}
+	case "ArrayLiteral":
+		node = &ArrayLiteral{
+			Token:    token.Token{Type: token.LBRACKET, Literal: "[", Pos: pos},
+			Elements: o.expressions("elements"),
+			Rbrack:   before(end),
+		}
+	case "HashLiteral":
+		n := &HashLiteral{
+			Token:  token.Token{Type: token.LBRACE, Literal: "{", Pos: pos},
+			Keys:   o.expressions("keys"),
+			Values: o.expressions("values"),
+			Rbrace: before(end),
+		}
+		if len(n.Keys) != len(n.Values) {
+			o.fail("values", fmt.Errorf("expected %d values got %d", len(n.Keys), len(n.Values)))
+		}
+		node = n
+	case "Index":
+		node = &Index{
+			Token:  token.Token{Type: token.LBRACKET, Literal: "[", Pos: o.position("lbrack")},
+			Left:   o.expression("left"),
+			Index:  o.expression("index"),
+			Rbrack: before(end),
+		}
+	case "Assign":
+		op := o.str("operator")
+		node = &Assign{
+			Token:    token.Token{Type: token.TokenType(op), Literal: op, Pos: o.position("opPos")},
+			Target:   o.expression("target"),
+			Operator: op,
+			Value:    o.expression("value"),
+		}
+	default:
+		return nil, fmt.Errorf("unknown node kind %q", o.kind)
+	}
+
+	if o.err != nil {
+		return nil, o.err
+	}
+
+	return node, nil
+}
+
+// firstToken returns the first token of the expression.
+func firstToken(exp Expression) token.Token {
+	switch exp := exp.(type) {
+	case *Infix:
+		return firstToken(exp.Left)
+	case *Call:
+		return firstToken(exp.Function)
+	case *Index:
+		return firstToken(exp.Left)
+	case *Assign:
+		return firstToken(exp.Target)
+	case *Identifier:
+		return exp.Token
+	case *IntegerLiteral:
+		return exp.Token
+	case *FloatLiteral:
+		return exp.Token
+	case *Boolean:
+		return exp.Token
+	case *StringLiteral:
+		return exp.Token
+	case *Prefix:
+		return exp.Token
+	case *Paren:
+		return exp.Token
+	case *If:
+		return exp.Token
+	case *Function:
+		return exp.Token
+	case *ArrayLiteral:
+		return exp.Token
+	case *HashLiteral:
+		return exp.Token
+	}
+
+	return token.Token{}
+}
+
+// before returns the position of the single character delimiter
+// immediately preceding the end position.
+func before(end token.Position) token.Position {
+	if !end.IsValid() {
+		return token.Position{}
+	}
+
+	return token.Position{Offset: end.Offset - 1, Line: end.Line, Column: end.Column - 1}
+}
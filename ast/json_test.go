@@ -0,0 +1,104 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pmatseykanets/monkey/ast"
+	"github.com/pmatseykanets/monkey/token"
+)
+
+func TestEncodeJSON(t *testing.T) {
+	prg := parse(t, "-x[0];")
+
+	got, err := ast.EncodeJSON(prg)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	want := `{"kind":"Program","pos":{"offset":0,"line":1,"column":1},"end":{"offset":5,"line":1,"column":6},"statements":[` +
+		`{"kind":"BareExpr","pos":{"offset":0,"line":1,"column":1},"end":{"offset":5,"line":1,"column":6},"value":` +
+		`{"kind":"Prefix","pos":{"offset":0,"line":1,"column":1},"end":{"offset":5,"line":1,"column":6},"operator":"-","right":` +
+		`{"kind":"Index","pos":{"offset":1,"line":1,"column":2},"end":{"offset":5,"line":1,"column":6},"lbrack":{"offset":2,"line":1,"column":3},` +
+		`"left":{"kind":"Identifier","pos":{"offset":1,"line":1,"column":2},"end":{"offset":2,"line":1,"column":3},"value":"x"},` +
+		`"index":{"kind":"IntegerLiteral","pos":{"offset":3,"line":1,"column":4},"end":{"offset":4,"line":1,"column":5},"literal":"0","value":0}}}}]}`
+	if want != string(got) {
+		t.Errorf("Expected\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestEncodeJSONWithoutPositions(t *testing.T) {
+	node := &ast.If{
+		Token:       token.Token{Type: token.IF, Literal: "if"},
+		Condition:   &ast.Boolean{Token: token.Token{Type: token.TRUE, Literal: "true"}, Value: true},
+		Consequence: &ast.Block{Token: token.Token{Type: token.LBRACE, Literal: "{"}, Statements: []ast.Statement{}},
+	}
+
+	data, err := ast.EncodeJSON(node)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	want := `{"kind":"If","pos":null,"end":null,` +
+		`"condition":{"kind":"Boolean","pos":null,"end":null,"value":true},` +
+		`"consequence":{"kind":"Block","pos":null,"end":null,"statements":[]},` +
+		`"alternative":null}`
+	if want != string(data) {
+		t.Errorf("Expected\n%s\ngot\n%s", want, data)
+	}
+
+	got, err := ast.DecodeJSON(data)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if diff := cmp.Diff(ast.Node(node), got); diff != "" {
+		t.Errorf("Unexpected node (-want +got):\n%s", diff)
+	}
+}
+
+func TestDecodeJSONErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`null`, "decode ast: null node"},
+		{`{}`, "decode ast: missing node kind"},
+		{`{"kind":"Foo"}`, `decode ast: unknown node kind "Foo"`},
+		{`{"kind":"Identifier","value":1}`, "decode ast: Identifier.value: json: cannot unmarshal number into Go value of type string"},
+		{`{"kind":"Let","name":{"kind":"Identifier","value":"x"},"value":{"kind":"Break"}}`, "decode ast: Let.value: expected an expression got *ast.Break"},
+		{`{"kind":"Program","statements":[{"kind":"Identifier"}]}`, "decode ast: Program.statements: expected a statement got *ast.Identifier"},
+		{`{"kind":"Function","args":[{"kind":"Break"}],"body":{"kind":"Block","statements":[]}}`, "decode ast: Function.args: expected *ast.Identifier got *ast.Break"},
+		{`{"kind":"While","condition":{"kind":"Boolean","value":true},"body":{"kind":"Break"}}`, "decode ast: While.body: expected *ast.Block got *ast.Break"},
+		{`{"kind":"HashLiteral","keys":[{"kind":"Identifier"}],"values":[]}`, "decode ast: HashLiteral.values: expected 1 values got 0"},
+		{`{"kind":"Return","value":{"kind":"Prefix","right":{}}}`, "decode ast: missing node kind"},
+		{`{"kind":"Let"}`, "decode ast: Let.name: missing node"},
+		{`{"kind":"Let","name":{"kind":"Identifier","value":"x"},"value":null}`, "decode ast: Let.value: missing node"},
+		{
+			`{"kind":"Program","statements":[{"kind":"BareExpr","value":{"kind":"Infix","operator":"+"}}]}`,
+			"decode ast: Infix.left: missing node",
+		},
+		{`{"kind":"Infix","operator":"+","left":{"kind":"Identifier","value":"x"}}`, "decode ast: Infix.right: missing node"},
+		{`{"kind":"Prefix","operator":"-"}`, "decode ast: Prefix.right: missing node"},
+		{`{"kind":"Paren"}`, "decode ast: Paren.value: missing node"},
+		{`{"kind":"If","condition":{"kind":"Boolean","value":true}}`, "decode ast: If.consequence: missing node"},
+		{`{"kind":"Function","args":[]}`, "decode ast: Function.body: missing node"},
+		{`{"kind":"Call","args":[]}`, "decode ast: Call.function: missing node"},
+		{`{"kind":"Index","left":{"kind":"Identifier","value":"a"}}`, "decode ast: Index.index: missing node"},
+		{`{"kind":"Assign","operator":"=","value":{"kind":"Identifier","value":"a"}}`, "decode ast: Assign.target: missing node"},
+		{`{"kind":"For","var":null}`, "decode ast: For.var: missing node"},
+		{`{"kind":"While","body":{"kind":"Block","statements":[]}}`, "decode ast: While.condition: missing node"},
+		{`{"kind":"ArrayLiteral","elements":[null]}`, "decode ast: ArrayLiteral.elements: null node at index 0"},
+		{`{"kind":"Program","statements":[{"kind":"Break"},null]}`, "decode ast: Program.statements: null node at index 1"},
+	}
+
+	for _, tt := range tests {
+		_, err := ast.DecodeJSON([]byte(tt.input))
+		if err == nil {
+			t.Errorf("%s: expected error %q", tt.input, tt.want)
+			continue
+		}
+		if got := err.Error(); tt.want != got {
+			t.Errorf("%s: expected error %q got %q", tt.input, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package ast
+
+import "fmt"
+
+// ModifierFunc returns the node replacing the node passed to it.
+// Returning the node itself leaves it unchanged.
+type ModifierFunc func(Node) Node
+
+// Modify traverses an AST in depth-first order replacing each node
+// with the result of the modifier. The children of a node are modified
+// before the node itself, so the modifier sees the node with
+// its children already replaced. The tree is updated in place and
+// the replacement of the node is returned.
+//
+// A replacement must fit into the place of the node it replaces:
+// statements are replaced with statements, expressions with
+// expressions, and blocks and identifiers, such as function arguments,
+// with blocks and identifiers. Modify panics otherwise.
+// Nil children, e.g. the missing nodes of trees with parse errors,
+// are left as they are.
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch n := node.(type) {
+	case *Program:
+		modifyStatements(n.Statements, modifier)
+
+	case *Let:
+		n.Name = modifyIdentifier(n.Name, modifier)
+		n.Value = modifyExpression(n.Value, modifier)
+
+	case *Return:
+		n.Value = modifyExpression(n.Value, modifier)
+
+	case *BareExpr:
+		n.Value = modifyExpression(n.Value, modifier)
+
+	case *Block:
+		modifyStatements(n.Statements, modifier)
+
+	case *While:
+		n.Condition = modifyExpression(n.Condition, modifier)
+		n.Body = modifyBlock(n.Body, modifier)
+
+	case *For:
+		n.Var = modifyIdentifier(n.Var, modifier)
+		n.Iterable = modifyExpression(n.Iterable, modifier)
+		n.Body = modifyBlock(n.Body, modifier)
+
+	case *Prefix:
+		n.Right = modifyExpression(n.Right, modifier)
+
+	case *Infix:
+		n.Left = modifyExpression(n.Left, modifier)
+		n.Right = modifyExpression(n.Right, modifier)
+
+	case *Paren:
+		n.Value = modifyExpression(n.Value, modifier)
+
+	case *If:
+		n.Condition = modifyExpression(n.Condition, modifier)
+		n.Consequence = modifyBlock(n.Consequence, modifier)
+		n.Alternative = modifyBlock(n.Alternative, modifier)
+
+	case *Function:
+		for i := range n.Args {
+			n.Args[i] = modifyIdentifier(n.Args[i], modifier)
+		}
+		n.Body = modifyBlock(n.Body, modifier)
+
+	case *Call:
+		n.Function = modifyExpression(n.Function, modifier)
+		modifyExpressions(n.Args, modifier)
+
+	case *ArrayLiteral:
+		modifyExpressions(n.Elements, modifier)
+
+	case *HashLiteral:
+		for i := range n.Keys {
+			n.Keys[i] = modifyExpression(n.Keys[i], modifier)
+			n.Values[i] = modifyExpression(n.Values[i], modifier)
+		}
+
+	case *Index:
+		n.Left = modifyExpression(n.Left, modifier)
+		n.Index = modifyExpression(n.Index, modifier)
+
+	case *Assign:
+		n.Target = modifyExpression(n.Target, modifier)
+		n.Value = modifyExpression(n.Value, modifier)
+
+	case *Break, *Continue,
+		*Identifier, *IntegerLiteral, *FloatLiteral, *Boolean, *StringLiteral:
+		// Nothing to do.
+
+	default:
+		panic(fmt.Sprintf("ast.Modify: unexpected node type %T", n))
+	}
+
+	return modifier(node)
+}
+
+func modifyStatements(list []Statement, modifier ModifierFunc) {
+	for i, stmt := range list {
+		if isNil(stmt) {
+			continue
+		}
+		node := Modify(stmt, modifier)
+		s, ok := node.(Statement)
+		if !ok {
+			panic(fmt.Sprintf("ast.Modify: %T replaced with %T, expected a statement", stmt, node))
+		}
+		list[i] = s
+	}
+}
+
+func modifyExpressions(list []Expression, modifier ModifierFunc) {
+	for i, exp := range list {
+		list[i] = modifyExpression(exp, modifier)
+	}
+}
+
+func modifyExpression(exp Expression, modifier ModifierFunc) Expression {
+	if isNil(exp) {
+		return exp
+	}
+	node := Modify(exp, modifier)
+	e, ok := node.(Expression)
+	if !ok {
+		panic(fmt.Sprintf("ast.Modify: %T replaced with %T, expected an expression", exp, node))
+	}
+
+	return e
+}
+
+func modifyBlock(block *Block, modifier ModifierFunc) *Block {
+	if block == nil {
+		return nil
+	}
+	node := Modify(block, modifier)
+	b, ok := node.(*Block)
+	if !ok {
+		panic(fmt.Sprintf("ast.Modify: *ast.Block replaced with %T", node))
+	}
+
+	return b
+}
+
+func modifyIdentifier(ident *Identifier, modifier ModifierFunc) *Identifier {
+	if ident == nil {
+		return nil
+	}
+	node := Modify(ident, modifier)
+	id, ok := node.(*Identifier)
+	if !ok {
+		panic(fmt.Sprintf("ast.Modify: *ast.Identifier replaced with %T", node))
+	}
+
+	return id
+}
@@ -0,0 +1,162 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pmatseykanets/monkey/ast"
+	"github.com/pmatseykanets/monkey/lexer"
+	"github.com/pmatseykanets/monkey/parser"
+	"github.com/pmatseykanets/monkey/token"
+)
+
+func TestModify(t *testing.T) {
+	turnOneIntoTwo := func(node ast.Node) ast.Node {
+		n, ok := node.(*ast.IntegerLiteral)
+		if !ok || n.Value != 1 {
+			return node
+		}
+
+		return &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "2"}, Value: 2}
+	}
+	renameXToY := func(node ast.Node) ast.Node {
+		n, ok := node.(*ast.Identifier)
+		if !ok || n.Value != "x" {
+			return node
+		}
+
+		return &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: "y"}, Value: "y"}
+	}
+
+	tests := []struct {
+		input    string
+		modifier ast.ModifierFunc
+		want     string
+	}{
+		{"1", turnOneIntoTwo, "2"},
+		{"1 + 1", turnOneIntoTwo, "(2 + 2)"},
+		{"-1", turnOneIntoTwo, "(-2)"},
+		{"let a = 1;", turnOneIntoTwo, "let a = 2;"},
+		{"fn() { return 1; }", turnOneIntoTwo, "fn() return 2;"},
+		{"if (1) { 1 } else { 1 }", turnOneIntoTwo, "if2 2else2"},
+		{"f(1, 1)", turnOneIntoTwo, "f(2, 2)"},
+		{"[1, 1][1]", turnOneIntoTwo, "([2, 2][2])"},
+		{"{1: 1}", turnOneIntoTwo, "{2: 2}"},
+		{"a[1] = 1", turnOneIntoTwo, "((a[2]) = 2)"},
+		{"while (1) { 1 }", turnOneIntoTwo, "while2 2"},
+		{"for (e in 1) { 1 }", turnOneIntoTwo, "for(e in 2) 2"},
+		{"let x = fn(x) { x }; for (x in x) { x }", renameXToY, "let y = fn(y) y;for(y in y) y"},
+	}
+
+	for _, tt := range tests {
+		prg := parse(t, tt.input)
+		got := ast.Modify(prg, tt.modifier)
+		if got != prg {
+			t.Errorf("%q: expected the program to be returned", tt.input)
+		}
+		if got := prg.String(); tt.want != got {
+			t.Errorf("%q: expected %s got %s", tt.input, tt.want, got)
+		}
+	}
+}
+
+func TestModifyBottomUp(t *testing.T) {
+	// Folds additions of integer literals which only works
+	// if the operands have already been folded.
+	fold := func(node ast.Node) ast.Node {
+		n, ok := node.(*ast.Infix)
+		if !ok || n.Operator != "+" {
+			return node
+		}
+		left, ok := n.Left.(*ast.IntegerLiteral)
+		if !ok {
+			return node
+		}
+		right, ok := n.Right.(*ast.IntegerLiteral)
+		if !ok {
+			return node
+		}
+
+		return &ast.IntegerLiteral{Token: n.Token, Value: left.Value + right.Value}
+	}
+
+	prg := parse(t, "1 + 2 + 3 + x")
+	ast.Modify(prg, fold)
+
+	if want, got := "(6 + x)", prg.String(); want != got {
+		t.Errorf("Expected %s got %s", want, got)
+	}
+}
+
+func TestModifyPanics(t *testing.T) {
+	tests := []struct {
+		input    string
+		modifier ast.ModifierFunc
+		want     string
+	}{
+		{
+			"let a = 1;",
+			func(node ast.Node) ast.Node {
+				if _, ok := node.(*ast.IntegerLiteral); ok {
+					return &ast.Break{}
+				}
+				return node
+			},
+			"ast.Modify: *ast.IntegerLiteral replaced with *ast.Break, expected an expression",
+		},
+		{
+			"fn(a) { a }",
+			func(node ast.Node) ast.Node {
+				if _, ok := node.(*ast.Identifier); ok {
+					return &ast.IntegerLiteral{}
+				}
+				return node
+			},
+			"ast.Modify: *ast.Identifier replaced with *ast.IntegerLiteral",
+		},
+	}
+
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if got := recover(); tt.want != got {
+					t.Errorf("%q: expected panic %q got %v", tt.input, tt.want, got)
+				}
+			}()
+			ast.Modify(parse(t, tt.input), tt.modifier)
+		}()
+	}
+}
+
+func TestModifyParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string // The nodes passed to the modifier.
+	}{
+		{"let x = -;", []string{"Identifier x", "Prefix -", "Let", "Program"}},
+		{"let = 1;", []string{"BareExpr", "IntegerLiteral 1", "BareExpr", "Program"}},
+		{"x + ;", []string{"Identifier x", "Infix +", "BareExpr", "Program"}},
+		{"x = ;", []string{"Identifier x", "Assign =", "BareExpr", "Program"}},
+		{"f(1, ", []string{"Identifier f", "Call", "BareExpr", "Program"}},
+		{"if (x { 1 }", []string{"BareExpr", "BareExpr", "BareExpr", "Program"}},
+		{"for (i in ) { }", []string{"HashLiteral", "BareExpr", "Program"}},
+		{"while ( { }", []string{"Program"}},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.FromString(tt.input))
+		prg := p.Parse()
+		if len(p.Errors()) == 0 {
+			t.Fatalf("%q: expected parse errors", tt.input)
+		}
+
+		var got []string
+		ast.Modify(prg, func(n ast.Node) ast.Node {
+			got = append(got, nodeName(n))
+			return n
+		})
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%q: unexpected nodes (-want +got):\n%s", tt.input, diff)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package ast
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/pmatseykanets/monkey/token"
+)
+
+var (
+	nodeType  = reflect.TypeOf((*Node)(nil)).Elem()
+	tokenType = reflect.TypeOf(token.Token{})
+)
+
+// Fprint prints the structure of the syntax tree rooted at the node
+// to w, one node per line with its scalar fields and indented children.
+func Fprint(w io.Writer, node Node) {
+	printNode(w, "", reflect.ValueOf(node), 0)
+}
+
+func printNode(w io.Writer, label string, v reflect.Value, depth int) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() || v.IsNil() {
+		return
+	}
+
+	s := v.Elem()
+	t := s.Type()
+
+	var attrs []string
+	for i := 0; i < t.NumField(); i++ {
+		f := s.Field(i)
+		switch f.Kind() {
+		case reflect.String:
+			if f.String() != "" {
+				attrs = append(attrs, fmt.Sprintf("%s=%q", t.Field(i).Name, f.String()))
+			}
+		case reflect.Int64, reflect.Float64, reflect.Bool:
+			attrs = append(attrs, fmt.Sprintf("%s=%v", t.Field(i).Name, f.Interface()))
+		}
+	}
+
+	line := strings.Repeat("  ", depth) + label + t.Name()
+	if len(attrs) > 0 {
+		line += " " + strings.Join(attrs, " ")
+	}
+	fmt.Fprintln(w, line)
+
+	for i := 0; i < t.NumField(); i++ {
+		f, name := s.Field(i), t.Field(i).Name
+		switch {
+		case f.Type() == tokenType:
+		case f.Type().Implements(nodeType):
+			printNode(w, name+": ", f, depth+1)
+		case f.Kind() == reflect.Slice && f.Type().Elem().Implements(nodeType):
+			for j := 0; j < f.Len(); j++ {
+				printNode(w, fmt.Sprintf("%s[%d]: ", name, j), f.Index(j), depth+1)
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+func walkStatements(v Visitor, list []Statement) {
+	for _, stmt := range list {
+		walk(v, stmt)
+	}
+}
+
+func walkExpressions(v Visitor, list []Expression) {
+	for _, exp := range list {
+		walk(v, exp)
+	}
+}
+
+// isNil reports whether the node is missing. Trees returned by the
+// parser along with errors may have missing children, either nil
+// interfaces or nil pointers.
+func isNil(node Node) bool {
+	if node == nil {
+		return true
+	}
+	rv := reflect.ValueOf(node)
+
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// walk walks the child node unless it's nil.
+func walk(v Visitor, node Node) {
+	if isNil(node) {
+		return
+	}
+
+	Walk(v, node)
+}
+
+// Walk traverses an AST in depth-first order: It starts by calling v.Visit(node);
+// node must not be nil. If the visitor w returned by v.Visit(node) is not nil,
+// Walk is invoked recursively with visitor w for each of the non-nil children
+// of node, followed by a call of w.Visit(nil). Nil children include nil
+// pointers, e.g. the missing nodes of trees with parse errors.
+// Children are visited in the order they appear in the source.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		walkStatements(v, n.Statements)
+
+	case *Let:
+		walk(v, n.Name)
+		walk(v, n.Value)
+
+	case *Return:
+		walk(v, n.Value)
+
+	case *BareExpr:
+		walk(v, n.Value)
+
+	case *Block:
+		walkStatements(v, n.Statements)
+
+	case *While:
+		walk(v, n.Condition)
+		walk(v, n.Body)
+
+	case *For:
+		walk(v, n.Var)
+		walk(v, n.Iterable)
+		walk(v, n.Body)
+
+	case *Break, *Continue:
+		// Nothing to do.
+
+	case *Identifier, *IntegerLiteral, *FloatLiteral, *Boolean, *StringLiteral:
+		// Nothing to do.
+
+	case *Prefix:
+		walk(v, n.Right)
+
+	case *Infix:
+		walk(v, n.Left)
+		walk(v, n.Right)
+
+	case *Paren:
+		walk(v, n.Value)
+
+	case *If:
+		walk(v, n.Condition)
+		walk(v, n.Consequence)
+		walk(v, n.Alternative)
+
+	case *Function:
+		for _, arg := range n.Args {
+			walk(v, arg)
+		}
+		walk(v, n.Body)
+
+	case *Call:
+		walk(v, n.Function)
+		walkExpressions(v, n.Args)
+
+	case *ArrayLiteral:
+		walkExpressions(v, n.Elements)
+
+	case *HashLiteral:
+		for i := range n.Keys {
+			walk(v, n.Keys[i])
+			walk(v, n.Values[i])
+		}
+
+	case *Index:
+		walk(v, n.Left)
+		walk(v, n.Index)
+
+	case *Assign:
+		walk(v, n.Target)
+		walk(v, n.Value)
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order: It starts by calling
+// f(node); node must not be nil. If f returns true, Inspect invokes f
+// recursively for each of the non-nil children of node, followed by a
+// call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,196 @@
+package ast_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pmatseykanets/monkey/ast"
+	"github.com/pmatseykanets/monkey/lexer"
+	"github.com/pmatseykanets/monkey/parser"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.FromString(input))
+	prg := p.Parse()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("%q: parse errors %v", input, errs)
+	}
+
+	return prg
+}
+
+// nodeName returns the type name of the node and its literal
+// value if it has one.
+func nodeName(n ast.Node) string {
+	name := strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast.")
+	switch n := n.(type) {
+	case *ast.Identifier, *ast.IntegerLiteral, *ast.FloatLiteral, *ast.Boolean, *ast.StringLiteral:
+		return name + " " + n.String()
+	case *ast.Prefix:
+		return name + " " + n.Operator
+	case *ast.Infix:
+		return name + " " + n.Operator
+	case *ast.Assign:
+		return name + " " + n.Operator
+	}
+
+	return name
+}
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{
+			"let x = -1 + 2.5;",
+			[]string{"Program", "Let", "Identifier x", "Infix +", "Prefix -", "IntegerLiteral 1", "FloatLiteral 2.5"},
+		},
+		{
+			"if (a) { b } else { return c; }",
+			[]string{"Program", "BareExpr", "If", "Identifier a", "Block", "BareExpr", "Identifier b", "Block", "Return", "Identifier c"},
+		},
+		{
+			"if (true) { 1 }",
+			[]string{"Program", "BareExpr", "If", "Boolean true", "Block", "BareExpr", "IntegerLiteral 1"},
+		},
+		{
+			"let f = fn(a, b) { a }; f(1, \"s\");",
+			[]string{
+				"Program", "Let", "Identifier f", "Function", "Identifier a", "Identifier b", "Block", "BareExpr", "Identifier a",
+				"BareExpr", "Call", "Identifier f", "IntegerLiteral 1", "StringLiteral \"s\"",
+			},
+		},
+		{
+			"[1, {\"k\": 2, 3: 4}][0]",
+			[]string{
+				"Program", "BareExpr", "Index", "ArrayLiteral", "IntegerLiteral 1",
+				"HashLiteral", "StringLiteral \"k\"", "IntegerLiteral 2", "IntegerLiteral 3", "IntegerLiteral 4",
+				"IntegerLiteral 0",
+			},
+		},
+		{
+			"a[i] += 1",
+			[]string{"Program", "BareExpr", "Assign +=", "Index", "Identifier a", "Identifier i", "IntegerLiteral 1"},
+		},
+		{
+			"while (x) { break; } for (e in xs) { continue; }",
+			[]string{
+				"Program", "While", "Identifier x", "Block", "Break",
+				"For", "Identifier e", "Identifier xs", "Block", "Continue",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		ast.Inspect(parse(t, tt.input), func(n ast.Node) bool {
+			if n != nil {
+				got = append(got, nodeName(n))
+			}
+			return true
+		})
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%q: unexpected nodes (-want +got):\n%s", tt.input, diff)
+		}
+	}
+}
+
+func TestInspectSkipsChildren(t *testing.T) {
+	prg := parse(t, "let f = fn(x) { x + 1 }; f(2);")
+
+	var got []string
+	ast.Inspect(prg, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		got = append(got, nodeName(n))
+		_, isFunc := n.(*ast.Function)
+		return !isFunc
+	})
+
+	want := []string{"Program", "Let", "Identifier f", "Function", "BareExpr", "Call", "Identifier f", "IntegerLiteral 2"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected nodes (-want +got):\n%s", diff)
+	}
+}
+
+// depthVisitor records the nodes with their depth in the tree.
+type depthVisitor struct {
+	depth int
+	nodes *[]string
+}
+
+func (v depthVisitor) Visit(n ast.Node) ast.Visitor {
+	if n == nil {
+		*v.nodes = append(*v.nodes, strings.Repeat(".", v.depth-1)+"end")
+		return nil
+	}
+	*v.nodes = append(*v.nodes, strings.Repeat(".", v.depth)+nodeName(n))
+
+	return depthVisitor{depth: v.depth + 1, nodes: v.nodes}
+}
+
+func TestWalk(t *testing.T) {
+	var got []string
+	ast.Walk(depthVisitor{nodes: &got}, parse(t, "!x; y"))
+
+	want := []string{
+		"Program",
+		".BareExpr",
+		"..Prefix !",
+		"...Identifier x",
+		"...end",
+		"..end",
+		".end",
+		".BareExpr",
+		"..Identifier y",
+		"..end",
+		".end",
+		"end",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected nodes (-want +got):\n%s", diff)
+	}
+}
+
+func TestInspectParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"let = 1;", []string{"Program", "BareExpr", "BareExpr", "IntegerLiteral 1"}},
+		{"-;", []string{"Program", "BareExpr", "Prefix -"}},
+		{"x + ;", []string{"Program", "BareExpr", "Infix +", "Identifier x"}},
+		{"if (x { 1 }", []string{"Program", "BareExpr", "BareExpr", "BareExpr"}},
+		{"fn(a, { }", []string{"Program", "BareExpr", "BareExpr"}},
+		{"f(1, ", []string{"Program", "BareExpr", "Call", "Identifier f"}},
+		{"x[", []string{"Program", "BareExpr"}},
+		{"x = ;", []string{"Program", "BareExpr", "Assign =", "Identifier x"}},
+		{"for (i in ) { }", []string{"Program", "BareExpr", "HashLiteral"}},
+		{"while ( { }", []string{"Program"}},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.FromString(tt.input))
+		prg := p.Parse()
+		if len(p.Errors()) == 0 {
+			t.Fatalf("%q: expected parse errors", tt.input)
+		}
+
+		var got []string
+		ast.Inspect(prg, func(n ast.Node) bool {
+			if n != nil {
+				got = append(got, nodeName(n))
+			}
+			return true
+		})
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%q: unexpected nodes (-want +got):\n%s", tt.input, diff)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pmatseykanets/monkey"
+	"github.com/pmatseykanets/monkey/ast"
+	"github.com/pmatseykanets/monkey/lexer"
+	"github.com/pmatseykanets/monkey/parser"
+)
+
+const astUsage = `Usage: monkey ast [-json] [file]
+
+Prints the syntax tree of the file, or stdin if none.
+`
+
+// ast prints the syntax tree of the file given in args.
+func (c *command) ast(args []string) int {
+	flags := flag.NewFlagSet("monkey ast", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = func() {
+		fmt.Fprint(c.stderr, astUsage)
+		flags.PrintDefaults()
+	}
+	asJSON := flags.Bool("json", false, "print the tree as JSON")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if flags.NArg() > 1 {
+		fmt.Fprintln(c.stderr, "monkey ast: too many files")
+		flags.Usage()
+		return exitUsage
+	}
+
+	var (
+		path = "<stdin>"
+		src  []byte
+		err  error
+	)
+	if flags.NArg() == 0 {
+		src, err = ioutil.ReadAll(c.stdin)
+	} else {
+		path = flags.Arg(0)
+		src, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		fmt.Fprintf(c.stderr, "monkey ast: %v\n", err)
+		return exitError
+	}
+
+	p := parser.New(lexer.FromString(string(src)))
+	prg := p.Parse()
+	if len(p.Errors()) > 0 {
+		fmt.Fprintln(c.stderr, &monkey.Error{File: path, Parse: p.Errors()})
+		return exitError
+	}
+
+	if !*asJSON {
+		ast.Fprint(c.stdout, prg)
+		return exitOK
+	}
+
+	data, err := ast.EncodeJSON(prg)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "monkey ast: %v\n", err)
+		return exitError
+	}
+	var buf bytes.Buffer
+	json.Indent(&buf, data, "", "  ")
+	buf.WriteByte('\n')
+	c.stdout.Write(buf.Bytes())
+
+	return exitOK
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/pmatseykanets/monkey/format"
+)
+
+const fmtUsage = `Usage: monkey fmt [-w] [-d] [files...]
+
+Formats the files, or stdin if none, printing the result to stdout.
+`
+
+// fmt formats the files given in args.
+// With -d it prints the differences instead and exits with 1
+// if any of the files is not formatted.
+func (c *command) fmt(args []string) int {
+	flags := flag.NewFlagSet("monkey fmt", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = func() {
+		fmt.Fprint(c.stderr, fmtUsage)
+		flags.PrintDefaults()
+	}
+	write := flags.Bool("w", false, "write the result to the files instead of stdout")
+	diff := flags.Bool("d", false, "print diffs instead of the result and fail if there are any")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(c.stderr, "monkey fmt: can't use -w with stdin")
+			return exitUsage
+		}
+		src, err := ioutil.ReadAll(c.stdin)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "monkey fmt: %v\n", err)
+			return exitError
+		}
+		return c.format("<stdin>", src, false, *diff)
+	}
+
+	code := exitOK
+	for _, path := range flags.Args() {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "monkey fmt: %v\n", err)
+			code = exitError
+			continue
+		}
+		if rc := c.format(path, src, *write, *diff); rc != exitOK {
+			code = rc
+		}
+	}
+
+	return code
+}
+
+// format formats the src of the file at path
+// writing it back, printing the diff or the result.
+func (c *command) format(path string, src []byte, write, diff bool) int {
+	res, err := format.Source(src)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "%s: %v\n", path, err)
+		return exitError
+	}
+
+	changed := !bytes.Equal(src, res)
+	if diff {
+		if !changed {
+			return exitOK
+		}
+		fmt.Fprint(c.stdout, unifiedDiff(path+".orig", path, string(src), string(res)))
+		if !write {
+			return exitError
+		}
+	}
+
+	if !write {
+		c.stdout.Write(res)
+		return exitOK
+	}
+	if !changed {
+		return exitOK
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "monkey fmt: %v\n", err)
+		return exitError
+	}
+	if err := ioutil.WriteFile(path, res, fi.Mode().Perm()); err != nil {
+		fmt.Fprintf(c.stderr, "monkey fmt: %v\n", err)
+		return exitError
+	}
+
+	return exitOK
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pmatseykanets/monkey"
+	"github.com/pmatseykanets/monkey/lexer"
+	"github.com/pmatseykanets/monkey/lint"
+	"github.com/pmatseykanets/monkey/parser"
+)
+
+const lintUsage = `Usage: monkey lint [-format text|json|sarif] [-fix] [-disable rules] [files...]
+
+Checks the files, or stdin if none, and prints the problems found.
+Exits with 1 if there are any.
+`
+
+// scriptGlobals are the names exec binds for scripts.
+var scriptGlobals = []string{"puts", "eputs", "args", "exit"}
+
+// lintResult holds the diagnostics of a file.
+type lintResult struct {
+	path  string
+	diags []lint.Diagnostic
+}
+
+// lint checks the files given in args.
+// With -fix it applies the suggested fixes to the files
+// and reports the remaining problems.
+func (c *command) lint(args []string) int {
+	flags := flag.NewFlagSet("monkey lint", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = func() {
+		fmt.Fprint(c.stderr, lintUsage)
+		flags.PrintDefaults()
+		fmt.Fprintln(c.stderr, "\nRules:")
+		for _, rule := range lint.Rules {
+			fmt.Fprintf(c.stderr, "  %-20s %-8s %s\n", rule.ID, rule.Severity, rule.Doc)
+		}
+	}
+	format := flags.String("format", "text", "the output `format`: text, json or sarif")
+	fix := flags.Bool("fix", false, "apply the suggested fixes to the files")
+	disable := flags.String("disable", "", "a comma separated list of `rules` not to check")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+	switch *format {
+	case "text", "json", "sarif":
+	default:
+		fmt.Fprintf(c.stderr, "monkey lint: unknown format %q\n", *format)
+		return exitUsage
+	}
+	rules, err := enabledRules(*disable)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "monkey lint: %v\n", err)
+		return exitUsage
+	}
+
+	var (
+		results []lintResult
+		code    = exitOK
+	)
+	if flags.NArg() == 0 {
+		if *fix {
+			fmt.Fprintln(c.stderr, "monkey lint: can't use -fix with stdin")
+			return exitUsage
+		}
+		src, err := ioutil.ReadAll(c.stdin)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "monkey lint: %v\n", err)
+			return exitError
+		}
+		diags, ok := c.check("<stdin>", src, rules)
+		if !ok {
+			return exitError
+		}
+		results = append(results, lintResult{"<stdin>", diags})
+	}
+	for _, path := range flags.Args() {
+		diags, err := c.lintFile(path, rules, *fix)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "monkey lint: %v\n", err)
+			code = exitError
+			continue
+		}
+		if diags == nil {
+			code = exitError // The file failed to parse.
+			continue
+		}
+		results = append(results, lintResult{path, diags})
+	}
+
+	switch *format {
+	case "text":
+		for _, res := range results {
+			for _, d := range res.diags {
+				fmt.Fprintf(c.stdout, "%s:%s\n", res.path, d)
+			}
+		}
+	case "json":
+		data, _ := json.MarshalIndent(jsonDiagnostics(results), "", "  ")
+		fmt.Fprintf(c.stdout, "%s\n", data)
+	case "sarif":
+		data, _ := json.MarshalIndent(sarifLog(rules, results), "", "  ")
+		fmt.Fprintf(c.stdout, "%s\n", data)
+	}
+
+	for _, res := range results {
+		if len(res.diags) > 0 {
+			code = exitError
+		}
+	}
+
+	return code
+}
+
+// enabledRules returns the rules of the linter except
+// those in the comma separated list of IDs.
+func enabledRules(disable string) ([]*lint.Rule, error) {
+	known := make(map[string]bool)
+	for _, rule := range lint.Rules {
+		known[rule.ID] = true
+	}
+	disabled := make(map[string]bool)
+	for _, id := range strings.Split(disable, ",") {
+		if id = strings.TrimSpace(id); id == "" {
+			continue
+		}
+		if !known[id] {
+			return nil, fmt.Errorf("unknown rule %q", id)
+		}
+		disabled[id] = true
+	}
+
+	var rules []*lint.Rule
+	for _, rule := range lint.Rules {
+		if !disabled[rule.ID] {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, nil
+}
+
+// lintFile checks the file at path applying the fixes if fix is set.
+// The diagnostics are nil if the file fails to parse.
+func (c *command) lintFile(path string, rules []*lint.Rule, fix bool) ([]lint.Diagnostic, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	diags, ok := c.check(path, src, rules)
+	if !ok || !fix {
+		return diags, nil
+	}
+
+	res, n := lint.Apply(src, diags)
+	if n == 0 {
+		return diags, nil
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path, res, fi.Mode().Perm()); err != nil {
+		return nil, err
+	}
+
+	diags, _ = c.check(path, res, rules)
+	return diags, nil
+}
+
+// check parses the src of the file at path and checks it with the rules.
+// It prints the parse errors and reports false if there are any.
+func (c *command) check(path string, src []byte, rules []*lint.Rule) ([]lint.Diagnostic, bool) {
+	p := parser.New(lexer.FromString(string(src)))
+	prg := p.Parse()
+	if len(p.Errors()) > 0 {
+		fmt.Fprintln(c.stderr, &monkey.Error{File: path, Parse: p.Errors()})
+		return nil, false
+	}
+
+	diags := lint.Check(prg, rules, scriptGlobals...)
+	if diags == nil {
+		diags = []lint.Diagnostic{}
+	}
+
+	return diags, true
+}
+
+type jsonPosition struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type jsonFix struct {
+	Message string       `json:"message"`
+	Pos     jsonPosition `json:"pos"`
+	End     jsonPosition `json:"end"`
+	Text    string       `json:"text"`
+}
+
+type jsonDiagnostic struct {
+	File     string       `json:"file"`
+	Rule     string       `json:"rule"`
+	Severity string       `json:"severity"`
+	Pos      jsonPosition `json:"pos"`
+	End      jsonPosition `json:"end"`
+	Message  string       `json:"message"`
+	Fix      *jsonFix     `json:"fix,omitempty"`
+}
+
+// jsonDiagnostics returns the diagnostics of the results
+// in the form printed by -format json.
+func jsonDiagnostics(results []lintResult) []jsonDiagnostic {
+	list := []jsonDiagnostic{}
+	for _, res := range results {
+		for _, d := range res.diags {
+			jd := jsonDiagnostic{
+				File:     res.path,
+				Rule:     d.Rule,
+				Severity: d.Severity.String(),
+				Pos:      jsonPosition(d.Pos),
+				End:      jsonPosition(d.End),
+				Message:  d.Message,
+			}
+			if d.Fix != nil {
+				jd.Fix = &jsonFix{
+					Message: d.Fix.Message,
+					Pos:     jsonPosition(d.Fix.Pos),
+					End:     jsonPosition(d.Fix.End),
+					Text:    d.Fix.Text,
+				}
+			}
+			list = append(list, jd)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,124 @@
+// Command monkey runs Monkey scripts and the interactive REPL.
+//
+// Usage:
+//
+//	monkey                        start the REPL or run the script read from stdin
+//	monkey run script.mk [args]   run the script
+//	monkey -e 'expr' [args]       evaluate the expression and print its value
+//	monkey fmt [-w] [-d] [files]  format the files
+//	monkey ast [-json] [file]     print the syntax tree of the file
+//	monkey lint [-fix] [files]    check the files for likely mistakes
+//
+// Script arguments are available to scripts as the args array
+// and scripts can terminate with the exit(code) builtin.
+// Scripts can start with a shebang line, e.g.
+//
+//	#!/usr/bin/env -S monkey run
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pmatseykanets/monkey/repl"
+)
+
+// Exit codes.
+const (
+	exitOK    = 0
+	exitError = 1 // A script failed to parse or evaluate.
+	exitUsage = 2 // The command line is invalid.
+)
+
+const usage = `Usage:
+  monkey                        start the REPL or run the script read from stdin
+  monkey run script.mk [args]   run the script
+  monkey -e 'expr' [args]       evaluate the expression and print its value
+  monkey fmt [-w] [-d] [files]  format the files
+  monkey ast [-json] [file]     print the syntax tree of the file
+  monkey lint [-fix] [files]    check the files for likely mistakes
+`
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command with the args and returns the exit code.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("monkey", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+	}
+	expr := flags.String("e", "", "evaluate the `expression` and print its value")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	cmd := &command{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	if isFlagSet(flags, "e") {
+		return cmd.eval(ctx, *expr, flags.Args())
+	}
+
+	if flags.NArg() == 0 {
+		if isTerminal(stdin) {
+			fmt.Fprint(stdout, "Monkey REPL\n")
+			repl.Start(stdin, stdout)
+			return exitOK
+		}
+		return cmd.runStdin(ctx)
+	}
+
+	switch name := flags.Arg(0); name {
+	case "run":
+		if flags.NArg() < 2 {
+			fmt.Fprintln(stderr, "monkey run: missing script")
+			fmt.Fprint(stderr, usage)
+			return exitUsage
+		}
+		return cmd.runFile(ctx, flags.Arg(1), flags.Args()[2:])
+	case "fmt":
+		return cmd.fmt(flags.Args()[1:])
+	case "ast":
+		return cmd.ast(flags.Args()[1:])
+	case "lint":
+		return cmd.lint(flags.Args()[1:])
+	default:
+		fmt.Fprintf(stderr, "monkey: unknown command %q\n", name)
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+}
+
+// isFlagSet reports whether the flag with the name was set explicitly.
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
+
+// isTerminal reports whether the r is a character device, e.g. a terminal.
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return fi.Mode()&os.ModeCharDevice != 0
+}
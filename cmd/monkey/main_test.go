@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pmatseykanets/monkey/ast"
+	"github.com/pmatseykanets/monkey/lexer"
+	"github.com/pmatseykanets/monkey/lint"
+	"github.com/pmatseykanets/monkey/parser"
+)
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "monkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	script := filepath.Join(dir, "script.mk")
+	src := "#!/usr/bin/env -S monkey run\nputs(len(args));\nfor (arg in args) { puts(arg); }\n"
+	if err := ioutil.WriteFile(script, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args   []string
+		stdin  string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{[]string{"-e", "1 + 2"}, "", 0, "3\n", ""},
+		{[]string{"-e", "let x = 1;"}, "", 0, "", ""},
+		{[]string{"-e", "args", "a", "b"}, "", 0, "[a, b]\n", ""},
+		{[]string{"-e", "puts(1); exit(3); puts(2);"}, "", 3, "1\n", ""},
+		{[]string{"-e", "exit()"}, "", 0, "", ""},
+		{[]string{"-e", `exit("1")`}, "", 1, "", "runtime error: argument to `exit` must be INTEGER, got STRING\n"},
+		{[]string{"-e", "1 +"}, "", 1, "", "parse error: missing prefixFn for EOF\n"},
+		{[]string{"-e", "let f = fn() { 1 + f() }; f()"}, "", 1, "", "runtime error: maximum call depth exceeded\n" + strings.Repeat("\tat f (1:21)\n", 10)},
+		{[]string{"run", script, "a"}, "", 0, "1\na\n", ""},
+		{[]string{"run", filepath.Join(dir, "missing.mk")}, "", 1, "", "monkey: open " + filepath.Join(dir, "missing.mk") + ": no such file or directory\n"},
+		{nil, "puts(args); 1 / 0", 1, "[]\n", "runtime error: division by zero\n"},
+		{[]string{"run"}, "", 2, "", "monkey run: missing script\n" + usage},
+		{[]string{"fly"}, "", 2, "", "monkey: unknown command \"fly\"\n" + usage},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		code := run(context.Background(), tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+		if tt.code != code {
+			t.Errorf("%q: expected exit code %d got %d", tt.args, tt.code, code)
+		}
+		if got := stdout.String(); tt.stdout != got {
+			t.Errorf("%q: expected stdout %q got %q", tt.args, tt.stdout, got)
+		}
+		if got := stderr.String(); tt.stderr != got {
+			t.Errorf("%q: expected stderr %q got %q", tt.args, tt.stderr, got)
+		}
+	}
+}
+
+func TestFmt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "monkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	unformatted := "let x=1\n// note\nputs(x)\n"
+	formatted := "let x = 1;\n// note\nputs(x);\n"
+	bad := filepath.Join(dir, "bad.mk")
+	good := filepath.Join(dir, "good.mk")
+	invalid := filepath.Join(dir, "invalid.mk")
+	for path, src := range map[string]string{bad: unformatted, good: formatted, invalid: "let = 1;"} {
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	diff := "--- " + bad + ".orig\n+++ " + bad + "\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		"-let x=1\n" +
+		"+let x = 1;\n" +
+		" // note\n" +
+		"-puts(x)\n" +
+		"+puts(x);\n"
+
+	tests := []struct {
+		args   []string
+		stdin  string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{[]string{"fmt"}, unformatted, 0, formatted, ""},
+		{[]string{"fmt", bad, good}, "", 0, formatted + formatted, ""},
+		{[]string{"fmt", "-d", good}, "", 0, "", ""},
+		{[]string{"fmt", "-d", good, bad}, "", 1, diff, ""},
+		{[]string{"fmt", "-d"}, unformatted, 1, strings.Replace(diff, bad, "<stdin>", -1), ""},
+		{[]string{"fmt", invalid}, "", 1, "", invalid + ": parse error: expected token type IDENT got =; missing prefixFn for =\n"},
+		{[]string{"fmt", "-w"}, "", 2, "", "monkey fmt: can't use -w with stdin\n"},
+		{[]string{"fmt", "-w", bad}, "", 0, "", ""},
+		{[]string{"fmt", "-d", bad}, "", 0, "", ""},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		code := run(context.Background(), tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+		if tt.code != code {
+			t.Errorf("%q: expected exit code %d got %d", tt.args, tt.code, code)
+		}
+		if got := stdout.String(); tt.stdout != got {
+			t.Errorf("%q: expected stdout %q got %q", tt.args, tt.stdout, got)
+		}
+		if got := stderr.String(); tt.stderr != got {
+			t.Errorf("%q: expected stderr %q got %q", tt.args, tt.stderr, got)
+		}
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n"
+
+	want := "--- a\n+++ b\n" +
+		"@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n" +
+		"@@ -9,4 +9,3 @@\n 9\n 10\n 11\n-12\n"
+	if got := unifiedDiff("a", "b", a, b); want != got {
+		t.Errorf("Expected\n%s\ngot\n%s", want, got)
+	}
+
+	if got := unifiedDiff("a", "b", a, a); got != "" {
+		t.Errorf("Expected no diff got\n%s", got)
+	}
+}
+
+func TestAST(t *testing.T) {
+	dir, err := ioutil.TempDir("", "monkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "script.mk")
+	if err := ioutil.WriteFile(path, []byte("let x = -1;"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args   []string
+		stdin  string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{
+			[]string{"ast"}, "x", 0,
+			"Program\n  Statements[0]: BareExpr\n    Value: Identifier Value=\"x\"\n", "",
+		},
+		{
+			[]string{"ast", path}, "", 0,
+			"Program\n  Statements[0]: Let\n    Name: Identifier Value=\"x\"\n    Value: Prefix Operator=\"-\"\n      Right: IntegerLiteral Value=1\n", "",
+		},
+		{[]string{"ast", "-json"}, "let = 1;", 1, "", "<stdin>: parse error: expected token type IDENT got =; missing prefixFn for =\n"},
+		{[]string{"ast", path, path}, "", 2, "", "monkey ast: too many files\n" + astUsage + "  -json\n    \tprint the tree as JSON\n"},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		code := run(context.Background(), tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+		if tt.code != code {
+			t.Errorf("%q: expected exit code %d got %d", tt.args, tt.code, code)
+		}
+		if got := stdout.String(); tt.stdout != got {
+			t.Errorf("%q: expected stdout %q got %q", tt.args, tt.stdout, got)
+		}
+		if got := stderr.String(); tt.stderr != got {
+			t.Errorf("%q: expected stderr %q got %q", tt.args, tt.stderr, got)
+		}
+	}
+}
+
+func TestASTJSON(t *testing.T) {
+	src := "let f = fn(x) { x * 2 };\nf(21);"
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"ast", "-json"}, strings.NewReader(src), &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d got %d: %s", exitOK, code, stderr.String())
+	}
+
+	got, err := ast.DecodeJSON(stdout.Bytes())
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	want := parser.New(lexer.FromString(src)).Parse()
+	if diff := cmp.Diff(ast.Node(want), got); diff != "" {
+		t.Errorf("Unexpected tree (-want +got):\n%s", diff)
+	}
+}
+
+func TestLint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "monkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	clean := filepath.Join(dir, "clean.mk")
+	if err := ioutil.WriteFile(clean, []byte("puts(len(args));"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fixable := filepath.Join(dir, "fixable.mk")
+	if err := ioutil.WriteFile(fixable, []byte("let f = fn(x) { return x; x }"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args   []string
+		stdin  string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{[]string{"lint", clean}, "", 0, "", ""},
+		{[]string{"lint"}, "let len = 1; len == len", 1, "<stdin>:1:5: warning: len shadows a predeclared name (shadowed-builtin)\n<stdin>:1:14: warning: len compared with itself (self-comparison)\n", ""},
+		{[]string{"lint", "-disable", "shadowed-builtin, self-comparison"}, "let len = 1; len == len", 0, "", ""},
+		{[]string{"lint", "-format", "json"}, "1", 0, "[]\n", ""},
+		{[]string{"lint", clean, fixable}, "", 1, fixable + ":1:27: warning: unreachable code (unreachable-code)\n", ""},
+		{[]string{"lint", "-fix", fixable}, "", 0, "", ""},
+		{[]string{"lint"}, "let = 1;", 1, "", "<stdin>: parse error: expected token type IDENT got =; missing prefixFn for =\n"},
+		{[]string{"lint", "-fix"}, "", 2, "", "monkey lint: can't use -fix with stdin\n"},
+		{[]string{"lint", "-format", "xml"}, "", 2, "", "monkey lint: unknown format \"xml\"\n"},
+		{[]string{"lint", "-disable", "typo"}, "", 2, "", "monkey lint: unknown rule \"typo\"\n"},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		code := run(context.Background(), tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+		if tt.code != code {
+			t.Errorf("%q: expected exit code %d got %d", tt.args, tt.code, code)
+		}
+		if got := stdout.String(); tt.stdout != got {
+			t.Errorf("%q: expected stdout %q got %q", tt.args, tt.stdout, got)
+		}
+		if got := stderr.String(); tt.stderr != got {
+			t.Errorf("%q: expected stderr %q got %q", tt.args, tt.stderr, got)
+		}
+	}
+
+	got, err := ioutil.ReadFile(fixable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "let f = fn(x) { return x; }"; string(got) != want {
+		t.Errorf("Expected fixed file %q got %q", want, got)
+	}
+}
+
+func TestLintFormats(t *testing.T) {
+	src := "fn() { return 1; 2 }"
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"lint", "-format", "json"}, strings.NewReader(src), &stdout, &stderr); code != exitError {
+		t.Fatalf("Expected exit code %d got %d: %s", exitError, code, stderr.String())
+	}
+	var diags []jsonDiagnostic
+	if err := json.Unmarshal(stdout.Bytes(), &diags); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	wantJSON := []jsonDiagnostic{{
+		File:     "<stdin>",
+		Rule:     "unreachable-code",
+		Severity: "warning",
+		Pos:      jsonPosition{Offset: 17, Line: 1, Column: 18},
+		End:      jsonPosition{Offset: 18, Line: 1, Column: 19},
+		Message:  "unreachable code",
+		Fix: &jsonFix{
+			Message: "remove the unreachable code",
+			Pos:     jsonPosition{Offset: 17, Line: 1, Column: 18},
+			End:     jsonPosition{Offset: 19, Line: 1, Column: 20},
+		},
+	}}
+	if diff := cmp.Diff(wantJSON, diags); diff != "" {
+		t.Errorf("Unexpected JSON diagnostics (-want +got):\n%s", diff)
+	}
+
+	stdout.Reset()
+	run(context.Background(), []string{"lint", "-format", "sarif", "-disable", "unused-param"}, strings.NewReader(src), &stdout, &stderr)
+	var log sarifRoot
+	if err := json.Unmarshal(stdout.Bytes(), &log); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if log.Version != sarifVersion || len(log.Runs) != 1 {
+		t.Fatalf("Expected a SARIF %s log with one run got %+v", sarifVersion, log)
+	}
+	if got, want := len(log.Runs[0].Tool.Driver.Rules), len(lint.Rules)-1; got != want {
+		t.Errorf("Expected %d rules got %d", want, got)
+	}
+	region := sarifRegion{StartLine: 1, StartColumn: 18, EndLine: 1, EndColumn: 19}
+	wantResults := []sarifResult{{
+		RuleID:  "unreachable-code",
+		Level:   "warning",
+		Message: sarifMessage{"unreachable code"},
+		Locations: []sarifLocation{{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: "<stdin>"},
+				Region:           region,
+			},
+		}},
+		Fixes: []sarifFix{{
+			Description: sarifMessage{"remove the unreachable code"},
+			ArtifactChanges: []sarifArtifactChange{{
+				ArtifactLocation: sarifArtifactLocation{URI: "<stdin>"},
+				Replacements: []sarifReplacement{{
+					DeletedRegion: sarifRegion{StartLine: 1, StartColumn: 18, EndLine: 1, EndColumn: 20},
+				}},
+			}},
+		}},
+	}}
+	if diff := cmp.Diff(wantResults, log.Runs[0].Results); diff != "" {
+		t.Errorf("Unexpected SARIF results (-want +got):\n%s", diff)
+	}
+}
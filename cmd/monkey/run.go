@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/pmatseykanets/monkey"
+	"github.com/pmatseykanets/monkey/object"
+)
+
+// command holds the standard streams of the command.
+type command struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// exitStatus is the cause of the error that stops
+// a script calling the exit builtin.
+type exitStatus struct {
+	code int
+}
+
+func (e *exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// runFile runs the script in the file at path.
+func (c *command) runFile(ctx context.Context, path string, args []string) int {
+	_, code := c.exec(args, func(interp *monkey.Interpreter) (object.Object, error) {
+		return interp.RunFile(ctx, path)
+	})
+	return code
+}
+
+// runStdin runs the script read from stdin.
+func (c *command) runStdin(ctx context.Context) int {
+	src, err := ioutil.ReadAll(c.stdin)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "monkey: %v\n", err)
+		return exitError
+	}
+
+	_, code := c.exec(nil, func(interp *monkey.Interpreter) (object.Object, error) {
+		return interp.Run(ctx, string(src))
+	})
+	return code
+}
+
+// eval evaluates the expr and prints its value unless it's null.
+func (c *command) eval(ctx context.Context, expr string, args []string) int {
+	result, code := c.exec(args, func(interp *monkey.Interpreter) (object.Object, error) {
+		return interp.Run(ctx, expr)
+	})
+	if result != nil && result != object.NULL {
+		fmt.Fprintln(c.stdout, result.Inspect())
+	}
+
+	return code
+}
+
+// exec runs a script with the interpreter set up for the args
+// and returns its result and the exit code.
+// The result is nil if the script failed or called exit.
+func (c *command) exec(args []string, run func(*monkey.Interpreter) (object.Object, error)) (object.Object, int) {
+	interp := monkey.New(monkey.WithStdout(c.stdout), monkey.WithStderr(c.stderr))
+
+	argv := &object.Array{Elements: make([]object.Object, len(args))}
+	for i, arg := range args {
+		argv.Elements[i] = &object.String{Value: arg}
+	}
+	interp.Set("args", argv)
+	interp.Set("exit", &object.Builtin{Name: "exit", Fn: exit})
+
+	result, err := run(interp)
+	if err == nil {
+		return result, exitOK
+	}
+
+	var status *exitStatus
+	if errors.As(err, &status) {
+		return nil, status.code
+	}
+
+	var merr *monkey.Error
+	if !errors.As(err, &merr) {
+		fmt.Fprintf(c.stderr, "monkey: %v\n", err)
+		return nil, exitError
+	}
+
+	fmt.Fprintln(c.stderr, merr)
+	if merr.Runtime != nil {
+		for _, frame := range merr.Runtime.Stack {
+			fmt.Fprintln(c.stderr, "\tat "+frame.String())
+		}
+	}
+
+	return nil, exitError
+}
+
+// exit stops the script with the exit code given
+// as the only optional argument, zero by default.
+func exit(args ...object.Object) object.Object {
+	code := exitOK
+	switch len(args) {
+	case 0:
+	case 1:
+		n, ok := args[0].(*object.Integer)
+		if !ok {
+			return &object.Error{Message: fmt.Sprintf("argument to `exit` must be INTEGER, got %s", args[0].Type())}
+		}
+		code = int(n.Value)
+	default:
+		return &object.Error{Message: fmt.Sprintf("wrong number of arguments: want=1, got=%d", len(args))}
+	}
+
+	err := &exitStatus{code: code}
+	return &object.Error{Message: err.Error(), Cause: err}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/pmatseykanets/monkey/lint"
+	"github.com/pmatseykanets/monkey/token"
+)
+
+// The subset of the SARIF 2.1.0 format printed by monkey lint -format sarif.
+// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifRoot struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	Fixes     []sarifFix      `json:"fixes,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion  `json:"deletedRegion"`
+	InsertedContent sarifMessage `json:"insertedContent"`
+}
+
+// sarifLog returns the SARIF log of a run of the rules with the results.
+func sarifLog(rules []*lint.Rule, results []lintResult) sarifRoot {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:  "monkey lint",
+			Rules: []sarifRule{},
+		}},
+		// Columns of positions count characters.
+		ColumnKind: "unicodeCodePoints",
+		Results:    []sarifResult{},
+	}
+	for _, rule := range rules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   rule.ID,
+			ShortDescription:     sarifMessage{rule.Doc},
+			DefaultConfiguration: sarifConfiguration{sarifLevel(rule.Severity)},
+		})
+	}
+
+	for _, res := range results {
+		artifact := sarifArtifactLocation{URI: filepath.ToSlash(res.path)}
+		for _, d := range res.diags {
+			result := sarifResult{
+				RuleID:  d.Rule,
+				Level:   sarifLevel(d.Severity),
+				Message: sarifMessage{d.Message},
+				Locations: []sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: artifact,
+						Region:           newSarifRegion(d.Pos, d.End),
+					},
+				}},
+			}
+			if d.Fix != nil {
+				result.Fixes = []sarifFix{{
+					Description: sarifMessage{d.Fix.Message},
+					ArtifactChanges: []sarifArtifactChange{{
+						ArtifactLocation: artifact,
+						Replacements: []sarifReplacement{{
+							DeletedRegion:   newSarifRegion(d.Fix.Pos, d.Fix.End),
+							InsertedContent: sarifMessage{d.Fix.Text},
+						}},
+					}},
+				}}
+			}
+			run.Results = append(run.Results, result)
+		}
+	}
+
+	return sarifRoot{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []sarifRun{run},
+	}
+}
+
+func newSarifRegion(pos, end token.Position) sarifRegion {
+	return sarifRegion{
+		StartLine:   pos.Line,
+		StartColumn: pos.Column,
+		EndLine:     end.Line,
+		EndColumn:   end.Column,
+	}
+}
+
+// sarifLevel returns the SARIF level of the severity.
+func sarifLevel(s lint.Severity) string {
+	switch s {
+	case lint.Error:
+		return "error"
+	case lint.Warning:
+		return "warning"
+	}
+
+	return "note"
+}
@@ -0,0 +1,60 @@
+package eval
+
+import (
+	"sort"
+
+	"github.com/pmatseykanets/monkey/object"
+)
+
+// builtins holds functions available in every environment.
+var builtins = map[string]*object.Builtin{
+	"len": {
+		Name: "len",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments: want=1, got=%d", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.String:
+				return &object.Integer{Value: int64(len(arg.Value))}
+			case *object.Array:
+				return &object.Integer{Value: int64(len(arg.Elements))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(len(arg.Pairs))}
+			}
+
+			return newError("argument to `len` not supported, got %s", args[0].Type())
+		},
+	},
+	"push": {
+		Name: "push",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments: want=2, got=%d", len(args))
+			}
+
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `push` must be ARRAY, got %s", args[0].Type())
+			}
+
+			elements := make([]object.Object, len(arr.Elements)+1)
+			copy(elements, arr.Elements)
+			elements[len(arr.Elements)] = args[1]
+
+			return &object.Array{Elements: elements}
+		},
+	},
+}
+
+// BuiltinNames returns the sorted names of the builtin functions.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
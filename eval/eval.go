@@ -1,41 +1,978 @@
 package eval
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
 	"github.com/pmatseykanets/monkey/ast"
 	"github.com/pmatseykanets/monkey/object"
+	"github.com/pmatseykanets/monkey/token"
+)
+
+var (
+	TRUE  = object.TRUE
+	FALSE = object.FALSE
+	NULL  = object.NULL
+
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
 )
 
+// DefaultMaxDepth is the default limit of nested function calls.
+const DefaultMaxDepth = 10000
+
+// maxStackTrace is the maximum number of the innermost
+// call stack frames reported with an error.
+const maxStackTrace = 10
+
 var (
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
-	NULL  = &object.Null{}
+	// ErrStepBudget is the cause of the error returned
+	// when the evaluation runs out of its step budget.
+	ErrStepBudget = errors.New("step budget exceeded")
+	// ErrMemoryQuota is the cause of the error returned
+	// when the evaluation runs out of its memory quota.
+	ErrMemoryQuota = errors.New("memory quota exceeded")
 )
 
-// Eval .
-func Eval(node ast.Node) object.Object {
+// Option configures the evaluation.
+type Option func(*evaluator)
+
+// WithMaxSteps limits the number of steps the evaluation may take.
+// A step is a function call or a loop iteration.
+// A non-positive n disables the limit.
+func WithMaxSteps(n int64) Option {
+	return func(e *evaluator) {
+		e.maxSteps = n
+	}
+}
+
+// WithMaxMemory limits the approximate number of bytes
+// the evaluation may allocate for strings, arrays, hashes and functions.
+// The memory is accounted when allocated and never released
+// so the limit applies to the total allocated over the evaluation.
+// A non-positive n disables the limit.
+func WithMaxMemory(n int64) Option {
+	return func(e *evaluator) {
+		e.maxMemory = n
+	}
+}
+
+// WithTimeout limits the wall-clock time the evaluation may take.
+// A non-positive d disables the limit.
+func WithTimeout(d time.Duration) Option {
+	return func(e *evaluator) {
+		e.timeout = d
+	}
+}
+
+// WithMaxDepth limits the depth of nested function calls to n.
+// Calls in tail position don't increase the depth.
+// A non-positive n disables the limit.
+func WithMaxDepth(n int) Option {
+	return func(e *evaluator) {
+		e.maxDepth = n
+	}
+}
+
+// evaluator holds the state of a single evaluation.
+type evaluator struct {
+	ctx       context.Context
+	callCtx   context.Context // The ctx passed to builtins carrying the evaluator.
+	maxDepth  int
+	maxSteps  int64
+	maxMemory int64
+	timeout   time.Duration
+	steps     int64
+	memory    int64          // The number of bytes allocated so far.
+	frames    []object.Frame // The call stack with the innermost call last.
+}
+
+// Eval evaluates the node in the given environment.
+func Eval(node ast.Node, env *object.Environment, opts ...Option) object.Object {
+	return EvalContext(context.Background(), node, env, opts...)
+}
+
+// EvalContext evaluates the node in the given environment
+// until it's done or the ctx is cancelled or the budgets
+// set with options are exhausted, whichever happens first.
+// The context and the step budget are checked on function calls
+// and loop iterations and the memory quota on allocations.
+// Stopping is reported with an error object whose Cause is
+// ctx.Err(), ErrStepBudget or ErrMemoryQuota.
+func EvalContext(ctx context.Context, node ast.Node, env *object.Environment, opts ...Option) object.Object {
+	e, cancel := newEvaluator(ctx, opts)
+	defer cancel()
+
+	return e.eval(node, env)
+}
+
+// Apply calls the function or builtin fn with the args.
+func Apply(fn object.Object, args []object.Object, opts ...Option) object.Object {
+	return ApplyContext(context.Background(), fn, args, opts...)
+}
+
+// ApplyContext calls the function or builtin fn with the args
+// under the same limits as EvalContext.
+// Functions remain callable after the evaluation that created them
+// has returned since they hold on to their environments.
+// The call must not run concurrently with other evaluations
+// sharing the environment.
+//
+// If the ctx is the one an evaluation passed to a builtin, the call
+// is a part of that evaluation: it shares its call depth, step budget,
+// memory quota and context, and the opts are ignored. So recursion
+// through builtins is bounded the same way as direct recursion.
+// Such a ctx must not be used after the builtin returns.
+func ApplyContext(ctx context.Context, fn object.Object, args []object.Object, opts ...Option) object.Object {
+	if e, ok := ctx.Value(evaluatorKey{}).(*evaluator); ok {
+		return e.applyFunction(fn, args, token.Position{})
+	}
+
+	e, cancel := newEvaluator(ctx, opts)
+	defer cancel()
+
+	return e.applyFunction(fn, args, token.Position{})
+}
+
+// newEvaluator creates an evaluator configured by the opts.
+// The returned cancel function must be called
+// once the evaluation is done.
+func newEvaluator(ctx context.Context, opts []Option) (*evaluator, context.CancelFunc) {
+	e := &evaluator{maxDepth: DefaultMaxDepth}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	cancel := func() {}
+	if e.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+	}
+	e.ctx = ctx
+	e.callCtx = context.WithValue(ctx, evaluatorKey{}, e)
+
+	return e, cancel
+}
+
+// evaluatorKey is the key of the evaluator
+// in the contexts passed to builtins.
+type evaluatorKey struct{}
+
+// step accounts for a single step of the evaluation
+// and returns an error if the evaluation must stop.
+func (e *evaluator) step() *object.Error {
+	e.steps++
+	if e.maxSteps > 0 && e.steps > e.maxSteps {
+		return newStopError(ErrStepBudget)
+	}
+
+	select {
+	case <-e.ctx.Done():
+		return newStopError(e.ctx.Err())
+	default:
+	}
+
+	return nil
+}
+
+// newStopError creates an error that stops the evaluation
+// because of the cause.
+func newStopError(cause error) *object.Error {
+	return &object.Error{Message: "evaluation stopped: " + cause.Error(), Cause: cause}
+}
+
+func (e *evaluator) eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
-		return evalStatements(node.Statements)
+		return e.evalProgram(node, env)
+	case *ast.Block:
+		return e.evalBlock(node, env)
 	case *ast.BareExpr:
-		return Eval(node.Value)
+		return e.eval(node.Value, env)
+	case *ast.If:
+		return e.evalIfExpression(node, env)
+	case *ast.While:
+		return e.evalWhileStatement(node, env)
+	case *ast.For:
+		return e.evalForStatement(node, env)
+	case *ast.Return:
+		return e.evalReturnStatement(node, env)
+	case *ast.Break:
+		return BREAK
+	case *ast.Continue:
+		return CONTINUE
+	case *ast.Let:
+		val := e.eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		env.Set(node.Name.Value, val)
+	case *ast.Identifier:
+		return evalIdentifier(node, env)
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+	case *ast.StringLiteral:
+		return e.track(&object.String{Value: node.Value})
 	case *ast.Boolean:
-		if node.Value {
-			return TRUE
+		return nativeBoolToBooleanObject(node.Value)
+	case *ast.ArrayLiteral:
+		elements := e.evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
 		}
-		return FALSE
+		return e.track(&object.Array{Elements: elements})
+	case *ast.HashLiteral:
+		return e.evalHashLiteral(node, env)
+	case *ast.Prefix:
+		right := e.eval(node.Right, env)
+		if isError(right) {
+			return right
+		}
+		return evalPrefixExpression(node.Operator, right)
+	case *ast.Infix:
+		left := e.eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		right := e.eval(node.Right, env)
+		if isError(right) {
+			return right
+		}
+		return e.track(evalInfixExpression(node.Operator, left, right))
+	case *ast.Paren:
+		return e.eval(node.Value, env)
+	case *ast.Index:
+		left := e.eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := e.eval(node.Index, env)
+		if isError(index) {
+			return index
+		}
+		return evalIndexExpression(left, index)
+	case *ast.Assign:
+		return e.evalAssignExpression(node, env)
+	case *ast.Function:
+		return e.track(&object.Function{Name: node.Name, Params: node.Args, Body: node.Body, Env: env})
+	case *ast.Call:
+		fn, args := e.evalCallee(node, env)
+		if isError(fn) {
+			return fn
+		}
+		return e.applyFunction(fn, args, node.Token.Pos)
 	}
 
 	return nil
 }
 
-func evalStatements(stmts []ast.Statement) object.Object {
+func (e *evaluator) evalProgram(prg *ast.Program, env *object.Environment) object.Object {
+	var result object.Object
+
+	for _, stmt := range prg.Statements {
+		result = e.eval(stmt, env)
+		if isError(result) {
+			return result
+		}
+		if rv, ok := result.(*object.ReturnValue); ok {
+			return e.unwrapReturnValue(rv)
+		}
+		switch result {
+		case BREAK:
+			return newError("break outside of loop")
+		case CONTINUE:
+			return newError("continue outside of loop")
+		}
+	}
+
+	return result
+}
+
+// evalBlock evaluates statements in the block until
+// it runs out of them or gets an error, a return value
+// or a loop control signal which are then propagated
+// to the enclosing construct.
+func (e *evaluator) evalBlock(block *ast.Block, env *object.Environment) object.Object {
 	var result object.Object
 
-	for _, stmt := range stmts {
-		result = Eval(stmt)
+	for _, stmt := range block.Statements {
+		result = e.eval(stmt, env)
+		if isInterrupt(result) {
+			return result
+		}
+	}
+
+	return result
+}
+
+// evalExpressions evaluates expressions left to right.
+// If an error occurs it's returned as the only element.
+func (e *evaluator) evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
+	result := make([]object.Object, 0, len(exps))
+
+	for _, exp := range exps {
+		evald := e.eval(exp, env)
+		if isError(evald) {
+			return []object.Object{evald}
+		}
+		result = append(result, evald)
 	}
 
 	return result
 }
+
+func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	// Resolved identifiers are not bound in the environments
+	// closer than their depth so the lookup can skip them.
+	if val, ok := env.Outer(node.Depth).Get(node.Value); ok {
+		return val
+	}
+	if builtin, ok := builtins[node.Value]; ok {
+		return builtin
+	}
+
+	return newError("identifier not found: %s", node.Value)
+}
+
+func newError(format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
+
+func isError(obj object.Object) bool {
+	return obj != nil && obj.Type() == object.ERROR_OBJ
+}
+
+func isReturn(obj object.Object) bool {
+	return obj != nil && obj.Type() == object.RETURN_OBJ
+}
+
+// isInterrupt reports whether the obj interrupts
+// the sequential evaluation of statements.
+func isInterrupt(obj object.Object) bool {
+	return isError(obj) || isReturn(obj) || obj == BREAK || obj == CONTINUE
+}
+
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case NULL, FALSE:
+		return false
+	}
+
+	return true
+}
+
+func nativeBoolToBooleanObject(b bool) *object.Boolean {
+	if b {
+		return TRUE
+	}
+	return FALSE
+}
+
+func evalPrefixExpression(operator string, right object.Object) object.Object {
+	switch operator {
+	case "!":
+		return evalBangOperatorExpression(right)
+	case "-":
+		return evalMinusPrefixOperatorExpression(right)
+	}
+
+	return newError("unknown operator: %s%s", operator, right.Type())
+}
+
+func evalBangOperatorExpression(right object.Object) object.Object {
+	switch right {
+	case TRUE:
+		return FALSE
+	case FALSE, NULL:
+		return TRUE
+	}
+
+	return FALSE
+}
+
+func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Integer:
+		return &object.Integer{Value: -right.Value}
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	}
+
+	return newError("unknown operator: -%s", right.Type())
+}
+
+func evalInfixExpression(operator string, left, right object.Object) object.Object {
+	switch {
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		return evalIntegerInfixExpression(operator, left.(*object.Integer), right.(*object.Integer))
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(operator, left.(*object.String), right.(*object.String))
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(operator, toFloat(left), toFloat(right))
+	case operator == "==":
+		return nativeBoolToBooleanObject(left == right)
+	case operator == "!=":
+		return nativeBoolToBooleanObject(left != right)
+	case left.Type() != right.Type():
+		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	}
+
+	return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+}
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+func toFloat(obj object.Object) float64 {
+	if i, ok := obj.(*object.Integer); ok {
+		return float64(i.Value)
+	}
+
+	return obj.(*object.Float).Value
+}
+
+func evalIntegerInfixExpression(operator string, left, right *object.Integer) object.Object {
+	l, r := left.Value, right.Value
+
+	switch operator {
+	case "+":
+		return &object.Integer{Value: l + r}
+	case "-":
+		return &object.Integer{Value: l - r}
+	case "*":
+		return &object.Integer{Value: l * r}
+	case "/":
+		if r == 0 {
+			return newError("division by zero")
+		}
+		return &object.Integer{Value: l / r}
+	case "**":
+		if r < 0 {
+			return newError("negative exponent: %d ** %d", l, r)
+		}
+		return &object.Integer{Value: Pow(l, r)}
+	case "<":
+		return nativeBoolToBooleanObject(l < r)
+	case ">":
+		return nativeBoolToBooleanObject(l > r)
+	case "==":
+		return nativeBoolToBooleanObject(l == r)
+	case "!=":
+		return nativeBoolToBooleanObject(l != r)
+	}
+
+	return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+}
+
+func evalFloatInfixExpression(operator string, l, r float64) object.Object {
+	switch operator {
+	case "+":
+		return &object.Float{Value: l + r}
+	case "-":
+		return &object.Float{Value: l - r}
+	case "*":
+		return &object.Float{Value: l * r}
+	case "/":
+		return &object.Float{Value: l / r}
+	case "**":
+		return &object.Float{Value: math.Pow(l, r)}
+	case "<":
+		return nativeBoolToBooleanObject(l < r)
+	case ">":
+		return nativeBoolToBooleanObject(l > r)
+	case "==":
+		return nativeBoolToBooleanObject(l == r)
+	case "!=":
+		return nativeBoolToBooleanObject(l != r)
+	}
+
+	return newError("unknown operator: %s %s %s", object.FLOAT_OBJ, operator, object.FLOAT_OBJ)
+}
+
+func evalStringInfixExpression(operator string, left, right *object.String) object.Object {
+	switch operator {
+	case "+":
+		return &object.String{Value: left.Value + right.Value}
+	case "==":
+		return nativeBoolToBooleanObject(left.Value == right.Value)
+	case "!=":
+		return nativeBoolToBooleanObject(left.Value != right.Value)
+	}
+
+	return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+}
+
+// Pow raises base to a non-negative power exp the way
+// the ** operator does, using exponentiation by squaring.
+// The result wraps around on overflow.
+func Pow(base, exp int64) int64 {
+	result := int64(1)
+	for exp > 0 {
+		if exp&1 == 1 {
+			result *= base
+		}
+		exp >>= 1
+		base *= base
+	}
+
+	return result
+}
+
+func (e *evaluator) evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := &object.Hash{}
+
+	for i := range node.Keys {
+		key := e.eval(node.Keys[i], env)
+		if isError(key) {
+			return key
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+
+		value := e.eval(node.Values[i], env)
+		if isError(value) {
+			return value
+		}
+
+		hash.Set(hashKey, value)
+	}
+
+	return e.track(hash)
+}
+
+func evalIndexExpression(left, index object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		elements := left.(*object.Array).Elements
+		i := index.(*object.Integer).Value
+		if i < 0 || i >= int64(len(elements)) {
+			return NULL
+		}
+		return elements[i]
+	case left.Type() == object.HASH_OBJ:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+		pair, ok := left.(*object.Hash).Pairs[key.HashKey()]
+		if !ok {
+			return NULL
+		}
+		return pair.Value
+	}
+
+	return newError("index operator not supported: %s[%s]", left.Type(), index.Type())
+}
+
+func (e *evaluator) evalAssignExpression(node *ast.Assign, env *object.Environment) object.Object {
+	switch target := ast.Unparen(node.Target).(type) {
+	case *ast.Identifier:
+		val := e.eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		if node.Operator != "=" {
+			curr, ok := env.Get(target.Value)
+			if !ok {
+				return newError("identifier not found: %s", target.Value)
+			}
+			val = e.evalCompoundValue(node.Operator, curr, val)
+			if isError(val) {
+				return val
+			}
+		}
+		if !env.Assign(target.Value, val) {
+			return newError("assignment to undeclared identifier: %s", target.Value)
+		}
+		return val
+	case *ast.Index:
+		return e.evalIndexAssignment(target, node, env)
+	}
+
+	return newError("invalid assignment target: %s", node.Target)
+}
+
+func (e *evaluator) evalIndexAssignment(target *ast.Index, node *ast.Assign, env *object.Environment) object.Object {
+	left := e.eval(target.Left, env)
+	if isError(left) {
+		return left
+	}
+	index := e.eval(target.Index, env)
+	if isError(index) {
+		return index
+	}
+	val := e.eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		elements := left.(*object.Array).Elements
+		i := index.(*object.Integer).Value
+		if i < 0 || i >= int64(len(elements)) {
+			return newError("index out of range: %d", i)
+		}
+		if node.Operator != "=" {
+			val = e.evalCompoundValue(node.Operator, elements[i], val)
+			if isError(val) {
+				return val
+			}
+		}
+		elements[i] = val
+		return val
+	case left.Type() == object.HASH_OBJ:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+		hash := left.(*object.Hash)
+		if node.Operator != "=" {
+			pair, ok := hash.Pairs[key.HashKey()]
+			if !ok {
+				return newError("key not found: %s", index.Inspect())
+			}
+			val = e.evalCompoundValue(node.Operator, pair.Value, val)
+			if isError(val) {
+				return val
+			}
+		}
+		if _, ok := hash.Pairs[key.HashKey()]; !ok {
+			if err := e.alloc(pairSize); err != nil {
+				return err
+			}
+		}
+		hash.Set(key, val)
+		return val
+	}
+
+	return newError("index assignment not supported: %s[%s]", left.Type(), index.Type())
+}
+
+// evalCompoundValue computes the new value for a compound assignment
+// such as x += 1 by applying the underlying infix operator.
+func (e *evaluator) evalCompoundValue(operator string, curr, val object.Object) object.Object {
+	return e.track(evalInfixExpression(strings.TrimSuffix(operator, "="), curr, val))
+}
+
+func (e *evaluator) evalIfExpression(node *ast.If, env *object.Environment) object.Object {
+	cond := e.eval(node.Condition, env)
+	if isError(cond) {
+		return cond
+	}
+
+	var result object.Object
+	switch {
+	case isTruthy(cond):
+		result = e.eval(node.Consequence, env)
+	case node.Alternative != nil:
+		result = e.eval(node.Alternative, env)
+	}
+
+	if result == nil {
+		return NULL
+	}
+
+	return result
+}
+
+func (e *evaluator) evalWhileStatement(node *ast.While, env *object.Environment) object.Object {
+	for {
+		if err := e.step(); err != nil {
+			return err
+		}
+
+		cond := e.eval(node.Condition, env)
+		if isError(cond) {
+			return cond
+		}
+		if !isTruthy(cond) {
+			return NULL
+		}
+
+		result := e.eval(node.Body, env)
+		if isError(result) || isReturn(result) {
+			return result
+		}
+		if result == BREAK {
+			return NULL
+		}
+	}
+}
+
+func (e *evaluator) evalForStatement(node *ast.For, env *object.Environment) object.Object {
+	iterable := e.eval(node.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	// body evaluates the loop body with the loop variable bound
+	// in a fresh environment. It reports false if the loop should stop.
+	var result object.Object
+	body := func(val object.Object) bool {
+		if err := e.step(); err != nil {
+			result = err
+			return false
+		}
+
+		loopEnv := object.NewEnclosedEnvironment(env)
+		loopEnv.Set(node.Var.Value, val)
+
+		result = e.eval(node.Body, loopEnv)
+		if isError(result) || isReturn(result) || result == BREAK {
+			return false
+		}
+		return true
+	}
+
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		// Iterate by index as the body may modify the array.
+		for i := 0; i < len(iterable.Elements); i++ {
+			if !body(iterable.Elements[i]) {
+				break
+			}
+		}
+	case *object.Hash:
+		// Iterate over a copy of the keys in insertion order
+		// as the body may add new ones.
+		for _, pair := range iterable.Ordered() {
+			if !body(pair.Key) {
+				break
+			}
+		}
+	case *object.String:
+		for _, r := range iterable.Value {
+			char := e.track(&object.String{Value: string(r)})
+			if isError(char) {
+				return char
+			}
+			if !body(char) {
+				break
+			}
+		}
+	case *object.Integer:
+		for i := int64(0); i < iterable.Value; i++ {
+			if !body(&object.Integer{Value: i}) {
+				break
+			}
+		}
+	default:
+		return newError("not iterable: %s", iterable.Type())
+	}
+
+	if isError(result) || isReturn(result) {
+		return result
+	}
+
+	return NULL
+}
+
+func (e *evaluator) evalReturnStatement(node *ast.Return, env *object.Environment) object.Object {
+	// A call in a return statement is always in tail position.
+	if call, ok := ast.Unparen(node.Value).(*ast.Call); ok {
+		val := e.evalTailCall(call, env)
+		if isError(val) {
+			return val
+		}
+		return &object.ReturnValue{Value: val}
+	}
+
+	val := e.eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	return &object.ReturnValue{Value: val}
+}
+
+// unwrapReturnValue extracts the returned value
+// completing a pending tail call if necessary.
+func (e *evaluator) unwrapReturnValue(rv *object.ReturnValue) object.Object {
+	if tc, ok := rv.Value.(*tailCall); ok {
+		return e.applyFunction(tc.fn, tc.args, tc.pos)
+	}
+
+	return rv.Value
+}
+
+// evalCallee evaluates the function and the arguments of the call.
+// If an error occurs it's returned in place of the function.
+func (e *evaluator) evalCallee(node *ast.Call, env *object.Environment) (object.Object, []object.Object) {
+	fn := e.eval(node.Function, env)
+	if isError(fn) {
+		return fn, nil
+	}
+
+	args := e.evalExpressions(node.Args, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0], nil
+	}
+
+	return fn, args
+}
+
+// tailCall is a call in tail position whose function and arguments
+// have been evaluated but which is yet to be applied.
+// It's returned instead of growing the Go stack and is
+// completed by the trampoline in applyFunction.
+type tailCall struct {
+	fn   object.Object
+	args []object.Object
+	pos  token.Position
+}
+
+func (*tailCall) Type() object.Type {
+	return "TAIL_CALL"
+}
+func (*tailCall) Inspect() string {
+	return "tail call"
+}
+
+func (e *evaluator) evalTailCall(node *ast.Call, env *object.Environment) object.Object {
+	fn, args := e.evalCallee(node, env)
+	if isError(fn) {
+		return fn
+	}
+
+	return &tailCall{fn: fn, args: args, pos: node.Token.Pos}
+}
+
+// applyFunction calls the fn with args from the position pos.
+// Calls in tail position of the function body are
+// executed iteratively so that the recursion depth is bounded.
+func (e *evaluator) applyFunction(fn object.Object, args []object.Object, pos token.Position) object.Object {
+	e.frames = append(e.frames, object.Frame{Pos: pos})
+	defer func() {
+		e.frames = e.frames[:len(e.frames)-1]
+	}()
+
+	for {
+		if err := e.step(); err != nil {
+			return err
+		}
+
+		if builtin, ok := fn.(*object.Builtin); ok {
+			// Builtin results are assumed to be freshly allocated.
+			if builtin.CtxFn != nil {
+				return e.track(builtin.CtxFn(e.callCtx, args...))
+			}
+			return e.track(builtin.Fn(args...))
+		}
+
+		function, ok := fn.(*object.Function)
+		if !ok {
+			return newError("not a function: %s", fn.Type())
+		}
+
+		// A tail call replaces the frame of the caller.
+		e.frames[len(e.frames)-1] = object.Frame{Function: function.Name, Pos: pos}
+		if e.maxDepth > 0 && len(e.frames) > e.maxDepth {
+			err := newError("maximum call depth exceeded")
+			err.Stack = e.stackTrace()
+			return err
+		}
+
+		if len(args) != len(function.Params) {
+			return newError("wrong number of arguments: want=%d, got=%d", len(function.Params), len(args))
+		}
+
+		env := object.NewEnclosedEnvironment(function.Env)
+		for i, param := range function.Params {
+			env.Set(param.Value, args[i])
+		}
+
+		result := e.evalTailBlock(function.Body, env)
+		if rv, ok := result.(*object.ReturnValue); ok {
+			result = rv.Value
+		}
+
+		tc, ok := result.(*tailCall)
+		if !ok {
+			if result == nil {
+				return NULL
+			}
+			return result
+		}
+
+		fn, args, pos = tc.fn, tc.args, tc.pos
+	}
+}
+
+// stackTrace returns up to maxStackTrace innermost
+// frames of the call stack, the innermost first.
+func (e *evaluator) stackTrace() []object.Frame {
+	n := len(e.frames)
+	if n > maxStackTrace {
+		n = maxStackTrace
+	}
+
+	trace := make([]object.Frame, n)
+	for i := range trace {
+		trace[i] = e.frames[len(e.frames)-1-i]
+	}
+
+	return trace
+}
+
+// evalTailBlock evaluates the block like evalBlock
+// but with its last statement in tail position.
+func (e *evaluator) evalTailBlock(block *ast.Block, env *object.Environment) object.Object {
+	var result object.Object
+
+	for i, stmt := range block.Statements {
+		if i == len(block.Statements)-1 {
+			return e.evalTailStatement(stmt, env)
+		}
+
+		result = e.eval(stmt, env)
+		if isInterrupt(result) {
+			return result
+		}
+	}
+
+	return result
+}
+
+// evalTailStatement evaluates the statement in tail position.
+// A call expression is returned as a pending tail call
+// and so are calls in tail position of if branches.
+func (e *evaluator) evalTailStatement(stmt ast.Statement, env *object.Environment) object.Object {
+	expr, ok := stmt.(*ast.BareExpr)
+	if !ok {
+		return e.eval(stmt, env)
+	}
+
+	switch node := ast.Unparen(expr.Value).(type) {
+	case *ast.Call:
+		return e.evalTailCall(node, env)
+	case *ast.If:
+		cond := e.eval(node.Condition, env)
+		if isError(cond) {
+			return cond
+		}
+
+		var result object.Object
+		switch {
+		case isTruthy(cond):
+			result = e.evalTailBlock(node.Consequence, env)
+		case node.Alternative != nil:
+			result = e.evalTailBlock(node.Alternative, env)
+		}
+		if result == nil {
+			return NULL
+		}
+		return result
+	}
+
+	return e.eval(stmt, env)
+}
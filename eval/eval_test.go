@@ -1,11 +1,17 @@
 package eval
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
 
 	"github.com/pmatseykanets/monkey/lexer"
 	"github.com/pmatseykanets/monkey/object"
 	"github.com/pmatseykanets/monkey/parser"
+	"github.com/pmatseykanets/monkey/token"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -15,6 +21,24 @@ func TestEvalIntegerExpression(t *testing.T) {
 	}{
 		{"5", 5},
 		{"10", 10},
+		{"-5", -5},
+		{"-10", -10},
+		{"5 + 5 + 5 + 5 - 10", 10},
+		{"2 * 2 * 2 * 2 * 2", 32},
+		{"-50 + 100 + -50", 0},
+		{"5 * 2 + 10", 20},
+		{"5 + 2 * 10", 25},
+		{"20 + 2 * -10", 0},
+		{"50 / 2 * 2 + 10", 60},
+		{"2 * (5 + 10)", 30},
+		{"3 * (3 * 3) + 10", 37},
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"2 ** 10", 1024},
+		{"2 ** 0", 1},
+		{"2 ** 3 ** 2", 512},
+		{"(2 ** 3) ** 2", 64},
+		{"-2 ** 3", -8},
+		{"3 * 2 ** 2", 12},
 	}
 
 	for _, tt := range tests {
@@ -29,17 +53,629 @@ func TestEvalBooleanExpression(t *testing.T) {
 	}{
 		{"true", true},
 		{"false", false},
+		{"1 < 2", true},
+		{"1 > 2", false},
+		{"1 == 1", true},
+		{"1 != 1", false},
+		{"1.5 < 2", true},
+		{"2.0 == 2", true},
+		{"true == true", true},
+		{"true != false", true},
+		{"(1 < 2) == true", true},
+		{"!true", false},
+		{"!!5", true},
 	}
 
 	for _, tt := range tests {
 		testBooleanObject(t, testEval(tt.input), tt.want)
 	}
 }
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"3.14", 3.14},
+		{"-1.5", -1.5},
+		{"1.5 + 1.5", 3},
+		{"1 + 0.5", 1.5},
+		{"5 / 2.0", 2.5},
+		{"2.0 ** 3", 8},
+		{"4 ** 0.5", 2},
+		{"2 ** -1.0", 0.5},
+	}
+
+	for _, tt := range tests {
+		testFloatObject(t, testEval(tt.input), tt.want)
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"5 + true", "type mismatch: INTEGER + BOOLEAN"},
+		{"5 + true; 5", "type mismatch: INTEGER + BOOLEAN"},
+		{"-true", "unknown operator: -BOOLEAN"},
+		{"true + false", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"5; true ** false; 5", "unknown operator: BOOLEAN ** BOOLEAN"},
+		{"1 / 0", "division by zero"},
+		{"2 ** -1", "negative exponent: 2 ** -1"},
+		{"foobar", "identifier not found: foobar"},
+		{`"Hello" - "World"`, "unknown operator: STRING - STRING"},
+		{`{"name": "Monkey"}[[1]];`, "unusable as hash key: ARRAY"},
+		{"x = 1", "assignment to undeclared identifier: x"},
+		{"x += 1", "identifier not found: x"},
+		{"let a = [1]; a[1] = 2;", "index out of range: 1"},
+		{`let h = {}; h["k"] += 1;`, "key not found: k"},
+		{"let x = 1; x[0] = 1;", "index assignment not supported: INTEGER[INTEGER]"},
+		{"for (x in true) { x }", "not iterable: BOOLEAN"},
+		{"let i = 0; while (true) { i += 1; if (i > 2) { i + true; } }", "type mismatch: INTEGER + BOOLEAN"},
+		{"for (x in [1, 2]) { x + true; }", "type mismatch: INTEGER + BOOLEAN"},
+		{"if (10 > 1) { if (10 > 1) { return true + false; } return 1; }", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"let x = 5; x(1);", "not a function: INTEGER"},
+		{"let f = fn(x) { x }; f(1, 2);", "wrong number of arguments: want=1, got=2"},
+		{"let f = fn(x) { g(x) }; f(1);", "identifier not found: g"},
+		{"let f = fn(x) { if (x == 0) { x + true } else { f(x - 1) } }; f(10);", "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		got := testEval(tt.input)
+		err, ok := got.(*object.Error)
+		if !ok {
+			t.Errorf("Expected object.Error got %T (%v)", got, got)
+			continue
+		}
+		if want, got := tt.want, err.Message; want != got {
+			t.Errorf("Expected Message %q got %q", want, got)
+		}
+	}
+}
+
+func TestEvalLetStatement(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"let a = 5; a;", 5},
+		{"let a = 5 * 5; a;", 25},
+		{"let a = 5; let b = a; b;", 5},
+		{"let a = 5; let b = a; let c = a + b + 5; c;", 15},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.want)
+	}
+}
+
+func TestEvalStringExpression(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`"Hello World!"`, "Hello World!"},
+		{`"Hello" + " " + "World!"`, "Hello World!"},
+	}
+
+	for _, tt := range tests {
+		testStringObject(t, testEval(tt.input), tt.want)
+	}
+}
+
+func TestEvalArrayLiteral(t *testing.T) {
+	got := testEval("[1, 2 * 2, 3 + 3]")
+
+	arr, ok := got.(*object.Array)
+	if !ok {
+		t.Fatalf("Expected object.Array got %T (%v)", got, got)
+	}
+	if want, got := 3, len(arr.Elements); want != got {
+		t.Fatalf("Expected elements %d got %d", want, got)
+	}
+
+	testIntegerObject(t, arr.Elements[0], 1)
+	testIntegerObject(t, arr.Elements[1], 4)
+	testIntegerObject(t, arr.Elements[2], 6)
+}
+
+func TestEvalIndexExpression(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{}
+	}{
+		{"[1, 2, 3][0]", 1},
+		{"[1, 2, 3][2]", 3},
+		{"let i = 0; [1][i];", 1},
+		{"let a = [1, 2, 3]; a[0] + a[1] + a[2];", 6},
+		{"[1, 2, 3][3]", nil},
+		{"[1, 2, 3][-1]", nil},
+		{`{"foo": 5}["foo"]`, 5},
+		{`{"foo": 5}["bar"]`, nil},
+		{`let key = "foo"; {"foo": 5}[key]`, 5},
+		{`{}["foo"]`, nil},
+		{`{5: 5}[5]`, 5},
+		{`{true: 5}[true]`, 5},
+	}
+
+	for _, tt := range tests {
+		got := testEval(tt.input)
+		if want, ok := tt.want.(int); ok {
+			testIntegerObject(t, got, int64(want))
+			continue
+		}
+		testNullObject(t, got)
+	}
+}
+
+func TestEvalAssignExpression(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"let x = 1; x = 2; x;", 2},
+		{"let x = 1; x = x + 1;", 2},
+		{"let x = 1; x += 2; x;", 3},
+		{"let x = 5; x -= 2; x;", 3},
+		{"let x = 5; x *= 2; x;", 10},
+		{"let x = 10; x /= 2; x;", 5},
+		{"let x = 1; let y = 1; x = y = 5; x + y;", 10},
+		{"let a = [1, 2, 3]; a[1] = 5; a[1];", 5},
+		{"let a = [1, 2, 3]; a[2] += 5; a[2];", 8},
+		{`let h = {"k": 1}; h["k"] = 2; h["k"];`, 2},
+		{`let h = {}; h["k"] = 3; h["k"];`, 3},
+		{`let h = {"k": 1}; h["k"] *= 4;`, 4},
+		{"let a = [[1], [2]]; a[1][0] = 7; a[1][0];", 7},
+		{"let x = 1; (x) = 2; x;", 2},
+		{"let a = [1]; ((a[0])) += 2; a[0];", 3},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.want)
+	}
+}
+
+func TestEvalHashLiteral(t *testing.T) {
+	input := `let two = "two";
+	{
+		"one": 10 - 9,
+		two: 1 + 1,
+		"thr" + "ee": 6 / 2,
+		4: 4,
+		true: 5,
+		false: 6
+	}`
+
+	got := testEval(input)
+	hash, ok := got.(*object.Hash)
+	if !ok {
+		t.Fatalf("Expected object.Hash got %T (%v)", got, got)
+	}
+
+	want := map[object.HashKey]int64{
+		(&object.String{Value: "one"}).HashKey():   1,
+		(&object.String{Value: "two"}).HashKey():   2,
+		(&object.String{Value: "three"}).HashKey(): 3,
+		(&object.Integer{Value: 4}).HashKey():      4,
+		TRUE.HashKey():                             5,
+		FALSE.HashKey():                            6,
+	}
+	if want, got := len(want), len(hash.Pairs); want != got {
+		t.Fatalf("Expected pairs %d got %d", want, got)
+	}
+	for key, value := range want {
+		pair, ok := hash.Pairs[key]
+		if !ok {
+			t.Errorf("No pair for the given key")
+		}
+		testIntegerObject(t, pair.Value, value)
+	}
+}
+
+func TestEvalIfElseExpression(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{}
+	}{
+		{"if (true) { 10 }", 10},
+		{"if (false) { 10 }", nil},
+		{"if (1) { 10 }", 10},
+		{"if (1 < 2) { 10 }", 10},
+		{"if (1 > 2) { 10 }", nil},
+		{"if (1 > 2) { 10 } else { 20 }", 20},
+		{"if (1 < 2) { 10 } else { 20 }", 10},
+	}
+
+	for _, tt := range tests {
+		got := testEval(tt.input)
+		if want, ok := tt.want.(int); ok {
+			testIntegerObject(t, got, int64(want))
+			continue
+		}
+		testNullObject(t, got)
+	}
+}
+
+func TestEvalLoops(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"let i = 0; while (i < 10) { i += 1; } i;", 10},
+		{"let i = 0; while (false) { i += 1; } i;", 0},
+		{"let i = 0; while (true) { i += 1; if (i == 5) { break; } } i;", 5},
+		{"let i = 0; let n = 0; while (i < 10) { i += 1; if (i > 3) { continue; } n += 1; } n;", 3},
+		{"let sum = 0; for (x in [1, 2, 3]) { sum += x; } sum;", 6},
+		{"let sum = 0; for (x in 5) { sum += x; } sum;", 10},
+		{"let sum = 0; for (x in 0) { sum += x; } sum;", 0},
+		{`let sum = 0; let h = {1: "a", 2: "b", 3: "c"}; for (k in h) { sum += k; } sum;`, 6},
+		{`let n = 0; for (c in "héllo") { if (c == "l") { n += 1; } } n;`, 2},
+		{"let sum = 0; for (x in [1, 2, 3, 4]) { if (x == 3) { break; } sum += x; } sum;", 3},
+		{"let sum = 0; for (x in [1, 2, 3, 4]) { if (x == 2) { continue; } sum += x; } sum;", 8},
+		{"let n = 0; for (i in 3) { for (j in 3) { if (j == 1) { break; } n += 1; } } n;", 3},
+		{"let n = 0; let i = 0; while (i < 1000000) { i += 1; n += 2; } n;", 2000000},
+		{"let x = 2; while (x > 0) { x -= 1 }; x", 0},
+		{"let s = 0; for (i in 4) { s += i }; s", 6},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.want)
+	}
+}
+
+func TestEvalHashOrder(t *testing.T) {
+	input := `
+	let h = {"k": 0, 10: 1, "a": 2, true: 3, "z": 4, 3: 5, "m": 6, false: 7, "b": 8, 1: 9, "y": 10, "c": 11};
+	h["n"] = 12;
+	h["a"] = 13;
+	let keys = [];
+	for (k in h) { keys = push(keys, k); }
+	[keys, h]
+	`
+	want := "[[k, 10, a, true, z, 3, m, false, b, 1, y, c, n], " +
+		"{k: 0, 10: 1, a: 13, true: 3, z: 4, 3: 5, m: 6, false: 7, b: 8, 1: 9, y: 10, c: 11, n: 12}]"
+
+	for i := 0; i < 10; i++ {
+		if got := testEval(input).Inspect(); want != got {
+			t.Fatalf("Expected %s got %s", want, got)
+		}
+	}
+}
+
+func TestEvalReturnStatement(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"return 10;", 10},
+		{"return 10; 9;", 10},
+		{"return 2 * 5; 9;", 10},
+		{"9; return 2 * 5; 9;", 10},
+		{"if (10 > 1) { if (10 > 1) { return 10; } return 1; }", 10},
+		{"let f = fn() { while (true) { return 10; } }; f();", 10},
+		{"let f = fn() { for (x in [5, 10]) { if (x == 10) { return x; } } 0 }; f();", 10},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.want)
+	}
+}
+
+func TestEvalFunctionObject(t *testing.T) {
+	got := testEval("fn(x) { x + 2; };")
+
+	fn, ok := got.(*object.Function)
+	if !ok {
+		t.Fatalf("Expected object.Function got %T (%v)", got, got)
+	}
+	if want, got := 1, len(fn.Params); want != got {
+		t.Fatalf("Expected params %d got %d", want, got)
+	}
+	if want, got := "x", fn.Params[0].String(); want != got {
+		t.Errorf("Expected param %s got %s", want, got)
+	}
+	if want, got := "(x + 2)", fn.Body.String(); want != got {
+		t.Errorf("Expected body %s got %s", want, got)
+	}
+}
+
+func TestEvalFunctionApplication(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"let identity = fn(x) { x; }; identity(5);", 5},
+		{"let identity = fn(x) { return x; }; identity(5);", 5},
+		{"let double = fn(x) { x * 2; }; double(5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5, 5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
+		{"fn(x) { x; }(5)", 5},
+		{"let newAdder = fn(x) { fn(y) { x + y }; }; let addTwo = newAdder(2); addTwo(2);", 4},
+		{"let counter = fn() { let n = 0; fn() { n += 1; } }; let c = counter(); c(); c(); c();", 3},
+		{"let n = 1; let f = fn(x) { let n = 10; n = n + x; }; f(5); n;", 1},
+		{"let n = 1; let f = fn(x) { n = n + x; }; f(5); n;", 6},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.want)
+	}
+}
+
+func TestEvalTailCalls(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"let loop = fn(n) { if (n == 0) { 0 } else { loop(n - 1) } }; loop(1000000);", 0},
+		{"let loop = fn(n) { if (n == 0) { return 0; } return loop(n - 1); }; loop(100000);", 0},
+		{"let sum = fn(n, acc) { if (n == 0) { acc } else { sum(n - 1, acc + n) } }; sum(100000, 0);", 5000050000},
+		{`
+		let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } };
+		let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } };
+		if (even(100000)) { 1 } else { 0 };
+		`, 1},
+		{"let loop = fn(n) { while (true) { if (n == 0) { return 7; } return loop(n - 1); } }; loop(100000);", 7},
+		{"let fact = fn(n) { if (n == 0) { 1 } else { n * fact(n - 1) } }; fact(10);", 3628800},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.want)
+	}
+}
+
+func TestEvalMaxDepth(t *testing.T) {
+	input := `let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } };
+f(1000000);`
+
+	got := testEval(input)
+	err, ok := got.(*object.Error)
+	if !ok {
+		t.Fatalf("Expected object.Error got %T (%v)", got, got)
+	}
+	if want, got := "maximum call depth exceeded", err.Message; want != got {
+		t.Errorf("Expected Message %q got %q", want, got)
+	}
+	if want, got := maxStackTrace, len(err.Stack); want != got {
+		t.Fatalf("Expected stack frames %d got %d", want, got)
+	}
+	want := object.Frame{Function: "f", Pos: token.Position{Offset: 46, Line: 1, Column: 47}}
+	if got := err.Stack[0]; want != got {
+		t.Errorf("Expected frame %v got %v", want, got)
+	}
+}
+
+func TestEvalWithMaxDepth(t *testing.T) {
+	input := `let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } };
+let g = fn(n) { f(n) + 0 };
+fn(n) { g(n) }(3);`
+
+	prg := parser.New(lexer.FromString(input)).Parse()
+
+	// The call to g is in tail position and replaces the anonymous function frame.
+	testIntegerObject(t, Eval(prg, object.NewEnvironment(), WithMaxDepth(5)), 3)
+
+	got := Eval(prg, object.NewEnvironment(), WithMaxDepth(4))
+	err, ok := got.(*object.Error)
+	if !ok {
+		t.Fatalf("Expected object.Error got %T (%v)", got, got)
+	}
+
+	want := []object.Frame{
+		{Function: "f", Pos: token.Position{Offset: 46, Line: 1, Column: 47}},
+		{Function: "f", Pos: token.Position{Offset: 46, Line: 1, Column: 47}},
+		{Function: "f", Pos: token.Position{Offset: 46, Line: 1, Column: 47}},
+		{Function: "f", Pos: token.Position{Offset: 76, Line: 2, Column: 18}},
+		{Function: "g", Pos: token.Position{Offset: 96, Line: 3, Column: 10}},
+	}
+	if !cmp.Equal(want, err.Stack) {
+		t.Errorf("Unexpected stack trace %s", cmp.Diff(want, err.Stack))
+	}
+
+	wantInspect := `ERROR: maximum call depth exceeded
+	at f (1:47)
+	at f (1:47)
+	at f (1:47)
+	at f (2:18)
+	at g (3:10)`
+	if got := err.Inspect(); wantInspect != got {
+		t.Errorf("Expected Inspect %q got %q", wantInspect, got)
+	}
+}
+
+func TestEvalMaxDepthTailCalls(t *testing.T) {
+	input := "let loop = fn(n) { if (n == 0) { 0 } else { loop(n - 1) } }; loop(100);"
+
+	prg := parser.New(lexer.FromString(input)).Parse()
+
+	testIntegerObject(t, Eval(prg, object.NewEnvironment(), WithMaxDepth(1)), 0)
+}
+
+func TestEvalContextCancellation(t *testing.T) {
+	tests := []struct {
+		input string
+		opts  []Option
+		ctx   func() (context.Context, context.CancelFunc)
+		want  error
+	}{
+		{
+			input: "while (true) { }",
+			opts:  []Option{WithTimeout(10 * time.Millisecond)},
+			ctx:   func() (context.Context, context.CancelFunc) { return context.Background(), func() {} },
+			want:  context.DeadlineExceeded,
+		},
+		{
+			input: "let f = fn() { f() }; f();",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			want: context.DeadlineExceeded,
+		},
+		{
+			input: "for (i in 10) { }",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+			want: context.Canceled,
+		},
+		{
+			input: "let i = 0; while (true) { i += 1; }",
+			opts:  []Option{WithMaxSteps(1000)},
+			ctx:   func() (context.Context, context.CancelFunc) { return context.Background(), func() {} },
+			want:  ErrStepBudget,
+		},
+	}
+
+	for _, tt := range tests {
+		ctx, cancel := tt.ctx()
+		prg := parser.New(lexer.FromString(tt.input)).Parse()
+
+		got := EvalContext(ctx, prg, object.NewEnvironment(), tt.opts...)
+		cancel()
+
+		err, ok := got.(*object.Error)
+		if !ok {
+			t.Errorf("Expected object.Error got %T (%v)", got, got)
+			continue
+		}
+		if !errors.Is(err.Cause, tt.want) {
+			t.Errorf("Expected Cause %v got %v", tt.want, err.Cause)
+		}
+		if want, got := "evaluation stopped: "+tt.want.Error(), err.Message; want != got {
+			t.Errorf("Expected Message %q got %q", want, got)
+		}
+	}
+}
+
+func TestEvalWithMaxSteps(t *testing.T) {
+	input := "let f = fn(x) { x }; let sum = 0; for (i in 3) { sum += f(i); } sum;"
+	prg := parser.New(lexer.FromString(input)).Parse()
+
+	// 3 loop iterations and 3 function calls.
+	testIntegerObject(t, Eval(prg, object.NewEnvironment(), WithMaxSteps(6)), 3)
+
+	got := Eval(prg, object.NewEnvironment(), WithMaxSteps(5))
+	if err, ok := got.(*object.Error); !ok || err.Cause != ErrStepBudget {
+		t.Errorf("Expected step budget error got %T (%v)", got, got)
+	}
+}
+
+func TestEvalBuiltinFunctions(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{}
+	}{
+		{`len("")`, 0},
+		{`len("four")`, 4},
+		{`len([1, 2, 3])`, 3},
+		{`len({"a": 1})`, 1},
+		{`len(1)`, "argument to `len` not supported, got INTEGER"},
+		{`len("one", "two")`, "wrong number of arguments: want=1, got=2"},
+		{`len(push([1], 2))`, 2},
+		{`let a = [1]; push(a, 2); len(a);`, 1},
+		{`push(1, 1)`, "argument to `push` must be ARRAY, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		got := testEval(tt.input)
+
+		switch want := tt.want.(type) {
+		case int:
+			testIntegerObject(t, got, int64(want))
+		case string:
+			err, ok := got.(*object.Error)
+			if !ok {
+				t.Errorf("Expected object.Error got %T (%v)", got, got)
+				continue
+			}
+			if got := err.Message; want != got {
+				t.Errorf("Expected Message %q got %q", want, got)
+			}
+		}
+	}
+}
+
+func TestEvalWithMaxMemory(t *testing.T) {
+	tests := []struct {
+		input string
+		quota int64
+	}{
+		{`let a = []; while (true) { a = push(a, 1); }`, 1 << 20},
+		{`let s = "x"; while (true) { s = s + s; }`, 1 << 20},
+		{`let s = "x"; while (true) { s += s; }`, 1 << 20},
+		{`let h = {}; let i = 0; while (true) { h[i] = i; i += 1; }`, 1 << 20},
+		{`let fs = []; while (true) { fs = push(fs, fn() { 1 }); }`, 1 << 20},
+		{`let s = "abc"; for (c in s) { }`, 3 * stringSize},
+	}
+
+	for _, tt := range tests {
+		prg := parser.New(lexer.FromString(tt.input)).Parse()
+
+		got := Eval(prg, object.NewEnvironment(), WithMaxMemory(tt.quota))
+		err, ok := got.(*object.Error)
+		if !ok {
+			t.Errorf("Expected object.Error got %T (%v)", got, got)
+			continue
+		}
+		if err.Cause != ErrMemoryQuota {
+			t.Errorf("Expected Cause %v got %v", ErrMemoryQuota, err.Cause)
+		}
+	}
+}
+
+func TestEvalMemoryWithinQuota(t *testing.T) {
+	input := `let a = []; for (i in 100) { a = push(a, i); } len(a);`
+	prg := parser.New(lexer.FromString(input)).Parse()
+
+	testIntegerObject(t, Eval(prg, object.NewEnvironment(), WithMaxMemory(1<<20)), 100)
+}
+
+func TestApply(t *testing.T) {
+	input := "let counter = fn() { let n = 0; fn(d) { n += d; n } }; counter();"
+	prg := parser.New(lexer.FromString(input)).Parse()
+	fn := Eval(prg, object.NewEnvironment())
+
+	// The closure keeps its state across calls.
+	testIntegerObject(t, Apply(fn, []object.Object{&object.Integer{Value: 2}}), 2)
+	testIntegerObject(t, Apply(fn, []object.Object{&object.Integer{Value: 3}}), 5)
+
+	tests := []struct {
+		fn   object.Object
+		args []object.Object
+		want string
+	}{
+		{fn, nil, "wrong number of arguments: want=1, got=0"},
+		{fn, []object.Object{TRUE}, "type mismatch: INTEGER + BOOLEAN"},
+		{&object.Integer{Value: 1}, nil, "not a function: INTEGER"},
+	}
+
+	for _, tt := range tests {
+		got := Apply(tt.fn, tt.args)
+		err, ok := got.(*object.Error)
+		if !ok {
+			t.Errorf("Expected object.Error got %T (%v)", got, got)
+			continue
+		}
+		if got := err.Message; tt.want != got {
+			t.Errorf("Expected Message %q got %q", tt.want, got)
+		}
+	}
+
+	testIntegerObject(t, Apply(builtins["len"], []object.Object{&object.String{Value: "abc"}}), 3)
+
+	loop := Eval(parser.New(lexer.FromString("fn() { while (true) {} }")).Parse(), object.NewEnvironment())
+	got := Apply(loop, nil, WithMaxSteps(10))
+	if err, ok := got.(*object.Error); !ok || err.Cause != ErrStepBudget {
+		t.Errorf("Expected step budget error got %T (%v)", got, got)
+	}
+}
+
 func testEval(input string) object.Object {
 	p := parser.New(lexer.FromString(input))
 	prg := p.Parse()
 
-	return Eval(prg)
+	return Eval(prg, object.NewEnvironment())
 }
 
 func testIntegerObject(t *testing.T, obj object.Object, want int64) bool {
@@ -56,6 +692,20 @@ func testIntegerObject(t *testing.T, obj object.Object, want int64) bool {
 	return true
 }
 
+func testFloatObject(t *testing.T, obj object.Object, want float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("Expected object.Float got %T (%v)", obj, obj)
+		return false
+	}
+	if got := result.Value; want != got {
+		t.Errorf("Expected Value %v got %v", want, got)
+		return false
+	}
+
+	return true
+}
+
 func testBooleanObject(t *testing.T, obj object.Object, want bool) bool {
 	result, ok := obj.(*object.Boolean)
 	if !ok {
@@ -69,3 +719,26 @@ func testBooleanObject(t *testing.T, obj object.Object, want bool) bool {
 
 	return true
 }
+
+func testStringObject(t *testing.T, obj object.Object, want string) bool {
+	result, ok := obj.(*object.String)
+	if !ok {
+		t.Errorf("Expected object.String got %T (%v)", obj, obj)
+		return false
+	}
+	if got := result.Value; want != got {
+		t.Errorf("Expected Value %q got %q", want, got)
+		return false
+	}
+
+	return true
+}
+
+func testNullObject(t *testing.T, obj object.Object) bool {
+	if obj != NULL {
+		t.Errorf("Expected NULL got %T (%v)", obj, obj)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,56 @@
+package eval
+
+import "github.com/pmatseykanets/monkey/object"
+
+// Approximate sizes in bytes used for memory accounting.
+const (
+	objectSize   = 16                // An interface value plus the object header.
+	stringSize   = objectSize + 16   // A string object without its bytes.
+	arraySize    = objectSize + 24   // An array object without its elements.
+	hashSize     = objectSize + 48   // A hash object without its pairs.
+	pairSize     = 16 + 2*objectSize // A hash key with a hash pair.
+	functionSize = objectSize + 64   // A function object with its name, params, body and env.
+)
+
+// sizeOf returns the approximate shallow size of the obj.
+// Elements of arrays and values of hashes are accounted
+// for separately when they are allocated.
+func sizeOf(obj object.Object) int64 {
+	switch obj := obj.(type) {
+	case *object.String:
+		return stringSize + int64(len(obj.Value))
+	case *object.Array:
+		return arraySize + int64(len(obj.Elements))*objectSize
+	case *object.Hash:
+		return hashSize + int64(len(obj.Pairs))*pairSize
+	case *object.Function:
+		return functionSize
+	}
+
+	return 0
+}
+
+// alloc accounts for n bytes and returns an error
+// if the memory quota has been exceeded.
+func (e *evaluator) alloc(n int64) *object.Error {
+	e.memory += n
+	if e.maxMemory > 0 && e.memory > e.maxMemory {
+		return newStopError(ErrMemoryQuota)
+	}
+
+	return nil
+}
+
+// track accounts for the memory of the freshly allocated obj.
+// It returns either the obj or an error if the memory quota
+// has been exceeded.
+func (e *evaluator) track(obj object.Object) object.Object {
+	if obj == nil || isError(obj) {
+		return obj
+	}
+	if err := e.alloc(sizeOf(obj)); err != nil {
+		return err
+	}
+
+	return obj
+}
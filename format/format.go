@@ -0,0 +1,533 @@
+// Package format implements the canonical formatting of Monkey source code.
+//
+// The canonical style puts every statement on its own line indented
+// with four spaces per block level, keeps opening braces on the line
+// of the statement, separates operators with single spaces and uses
+// the minimal number of parentheses. Let, return, break and continue
+// statements and expression statements are terminated with semicolons
+// except for the last expression in a block which is its value.
+// Single blank lines between statements and all comments are preserved.
+package format
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/pmatseykanets/monkey/ast"
+	"github.com/pmatseykanets/monkey/lexer"
+	"github.com/pmatseykanets/monkey/parser"
+	"github.com/pmatseykanets/monkey/token"
+)
+
+const indentation = "    "
+
+// Error describes the failure to parse the source.
+type Error struct {
+	Errors []error // Errors reported by the parser.
+}
+
+func (e *Error) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i := range e.Errors {
+		msgs[i] = e.Errors[i].Error()
+	}
+
+	return "parse error: " + strings.Join(msgs, "; ")
+}
+
+// Source formats the src in the canonical style.
+// Formatting formatted source doesn't change it.
+// The returned error, if any, is of type *Error.
+func Source(src []byte) ([]byte, error) {
+	p := parser.New(lexer.FromString(string(src)))
+	prg := p.Parse()
+	if len(p.Errors()) > 0 {
+		return nil, &Error{Errors: p.Errors()}
+	}
+
+	pr := newPrinter(string(src))
+	pr.program(prg)
+
+	return pr.buf.Bytes(), nil
+}
+
+// printer prints the syntax tree interleaving it with
+// the comments of the source it was parsed from.
+type printer struct {
+	buf    bytes.Buffer
+	indent int
+	// fresh is set at the start of the output and blocks
+	// where blank lines are dropped.
+	fresh bool
+	// commented is the length of the output ending
+	// with a line ending in a comment.
+	commented int
+
+	toks     []token.Token       // The tokens of the source ending with EOF.
+	elems    []token.Token       // The tokens and comments of the source sorted by offset.
+	comments []token.Token       // The comments of the source.
+	next     int                 // The index of the next comment to print.
+	closing  map[int]token.Token // The closing brackets keyed by the offsets of the opening ones.
+}
+
+func newPrinter(src string) *printer {
+	p := &printer{closing: make(map[int]token.Token)}
+
+	l := lexer.FromString(src)
+	var open []token.Token
+	for {
+		tok := l.NextToken()
+		p.toks = append(p.toks, tok)
+		if tok.Type == token.EOF {
+			break
+		}
+
+		switch tok.Type {
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			open = append(open, tok)
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			if n := len(open); n > 0 {
+				p.closing[open[n-1].Pos.Offset] = tok
+				open = open[:n-1]
+			}
+		}
+	}
+	p.comments = l.Comments()
+
+	p.elems = append(append(p.elems, p.toks...), p.comments...)
+	sort.SliceStable(p.elems, func(i, j int) bool {
+		return p.elems[i].Pos.Offset < p.elems[j].Pos.Offset
+	})
+
+	return p
+}
+
+// write writes the s indenting it if it starts a line.
+func (p *printer) write(s string) {
+	if p.atLineStart() {
+		for i := 0; i < p.indent; i++ {
+			p.buf.WriteString(indentation)
+		}
+	}
+	p.buf.WriteString(s)
+}
+
+func (p *printer) newline() {
+	p.buf.WriteByte('\n')
+}
+
+func (p *printer) atLineStart() bool {
+	return p.buf.Len() == 0 || p.buf.Bytes()[p.buf.Len()-1] == '\n'
+}
+
+// prevLine returns the line of the source element,
+// a token or a comment, preceding the offset or 0 if there is none.
+func (p *printer) prevLine(offset int) int {
+	i := sort.Search(len(p.elems), func(i int) bool {
+		return p.elems[i].Pos.Offset >= offset
+	})
+	if i == 0 {
+		return 0
+	}
+
+	return p.elems[i-1].Pos.Line
+}
+
+// blankLine preserves a blank line in the source
+// before the element at the pos.
+func (p *printer) blankLine(pos token.Position) {
+	if !p.fresh && pos.Line > p.prevLine(pos.Offset)+1 {
+		p.newline()
+	}
+	p.fresh = false
+}
+
+// isTrailing reports whether the comment follows
+// a token on the same line.
+func (p *printer) isTrailing(comment token.Token) bool {
+	i := sort.Search(len(p.toks), func(i int) bool {
+		return p.toks[i].Pos.Offset >= comment.Pos.Offset
+	})
+
+	return i > 0 && p.toks[i-1].Pos.Line == comment.Pos.Line
+}
+
+// hasComments reports whether there are comments
+// to print before the offset.
+func (p *printer) hasComments(offset int) bool {
+	return p.next < len(p.comments) && p.comments[p.next].Pos.Offset < offset
+}
+
+// flush prints the comments preceding the offset.
+// It must be called at the start of a line.
+// Comments following a token on the same line are appended
+// to the last printed line unless it already ends in a comment,
+// others are printed on their own lines.
+func (p *printer) flush(offset int) {
+	for ; p.hasComments(offset); p.next++ {
+		comment := p.comments[p.next]
+		if p.isTrailing(comment) && p.buf.Len() > 0 && p.buf.Len() != p.commented {
+			p.buf.Truncate(p.buf.Len() - 1) // The newline.
+			p.write(" " + comment.Literal)
+		} else {
+			p.blankLine(comment.Pos)
+			p.write(comment.Literal)
+		}
+		p.newline()
+		p.commented = p.buf.Len()
+	}
+}
+
+func (p *printer) program(prg *ast.Program) {
+	p.fresh = true
+	p.statements(prg.Statements, p.toks[len(p.toks)-1].Pos.Offset+1, false)
+}
+
+// statements prints the stmts one per line followed by
+// the comments preceding the end offset.
+func (p *printer) statements(stmts []ast.Statement, end int, inBlock bool) {
+	for i, stmt := range stmts {
+		pos := statementPos(stmt)
+		p.flush(pos.Offset)
+		p.blankLine(pos)
+
+		var next ast.Statement
+		if i < len(stmts)-1 {
+			next = stmts[i+1]
+		}
+		p.statement(stmt, next, inBlock)
+		p.newline()
+	}
+	p.flush(end)
+}
+
+// statementPos returns the position of the first token of the stmt.
+func statementPos(stmt ast.Statement) token.Position {
+	switch stmt := stmt.(type) {
+	case *ast.Let:
+		return stmt.Token.Pos
+	case *ast.Return:
+		return stmt.Token.Pos
+	case *ast.BareExpr:
+		return stmt.Token.Pos
+	case *ast.While:
+		return stmt.Token.Pos
+	case *ast.For:
+		return stmt.Token.Pos
+	case *ast.Break:
+		return stmt.Token.Pos
+	case *ast.Continue:
+		return stmt.Token.Pos
+	case *ast.Block:
+		return stmt.Token.Pos
+	}
+
+	return token.Position{}
+}
+
+// statement prints the stmt. The next statement, if any,
+// is needed to decide whether a semicolon is required.
+func (p *printer) statement(stmt ast.Statement, next ast.Statement, inBlock bool) {
+	switch stmt := stmt.(type) {
+	case *ast.Let:
+		p.write("let " + stmt.Name.Value + " = ")
+		p.expr(stmt.Value, parser.LOWEST)
+		p.write(";")
+	case *ast.Return:
+		p.write("return ")
+		p.expr(stmt.Value, parser.LOWEST)
+		p.write(";")
+	case *ast.BareExpr:
+		p.expr(stmt.Value, parser.LOWEST)
+		if needsSemicolon(stmt, next, inBlock) {
+			p.write(";")
+		}
+	case *ast.While:
+		p.write("while (")
+		p.expr(stmt.Condition, parser.LOWEST)
+		p.write(") ")
+		p.block(stmt.Body)
+	case *ast.For:
+		p.write("for (" + stmt.Var.Value + " in ")
+		p.expr(stmt.Iterable, parser.LOWEST)
+		p.write(") ")
+		p.block(stmt.Body)
+	case *ast.Break:
+		p.write("break;")
+	case *ast.Continue:
+		p.write("continue;")
+	case *ast.Block:
+		p.block(stmt)
+	}
+}
+
+// needsSemicolon reports whether the expression statement stmt
+// must be terminated with a semicolon.
+// The last expression of a block is its value and goes without one.
+// So does an if expression unless the next statement would
+// continue it, e.g. as a call or an infix expression.
+func needsSemicolon(stmt *ast.BareExpr, next ast.Statement, inBlock bool) bool {
+	if _, ok := ast.Unparen(stmt.Value).(*ast.If); ok {
+		bare, ok := next.(*ast.BareExpr)
+		return ok && parser.Precedence(firstToken(bare.Value, parser.LOWEST)) > parser.LOWEST
+	}
+
+	return next != nil || !inBlock
+}
+
+// firstToken returns the type of the first token
+// of the exp printed with the precedence prec.
+func firstToken(exp ast.Expression, prec int) token.TokenType {
+	exp = ast.Unparen(exp)
+	if precedence(exp) < prec {
+		return token.LPAREN
+	}
+
+	switch exp := exp.(type) {
+	case *ast.Infix:
+		return firstToken(exp.Left, precedence(exp))
+	case *ast.Assign:
+		return firstToken(exp.Target, parser.ASSIGN+1)
+	case *ast.Call:
+		return firstToken(exp.Function, parser.CALL)
+	case *ast.Index:
+		return firstToken(exp.Left, parser.CALL)
+	case *ast.Prefix:
+		return exp.Token.Type
+	case *ast.ArrayLiteral:
+		return token.LBRACKET
+	}
+
+	// The rest can't continue an expression.
+	return token.IDENT
+}
+
+// block prints the block with its statements indented.
+func (p *printer) block(block *ast.Block) {
+	end := p.toks[len(p.toks)-1].Pos.Offset
+	if rbrace, ok := p.closing[block.Token.Pos.Offset]; ok {
+		end = rbrace.Pos.Offset
+	}
+
+	if len(block.Statements) == 0 && !p.hasComments(end) {
+		p.write("{}")
+		return
+	}
+
+	p.write("{")
+	p.newline()
+	p.indent++
+	p.fresh = true
+	p.statements(block.Statements, end, true)
+	p.indent--
+	p.write("}")
+}
+
+// The precedence of literals, identifiers and other operands
+// that never need parentheses.
+const operand = parser.INDEX + 1
+
+// precedence returns the precedence of the exp.
+func precedence(exp ast.Expression) int {
+	switch exp := ast.Unparen(exp).(type) {
+	case *ast.Infix:
+		return parser.Precedence(exp.Token.Type)
+	case *ast.Assign:
+		return parser.ASSIGN
+	case *ast.Prefix:
+		return parser.PREFIX
+	case *ast.Call:
+		return parser.CALL
+	case *ast.Index:
+		return parser.INDEX
+	}
+
+	return operand
+}
+
+// expr prints the exp enclosing it in parentheses
+// if it binds weaker than prec. The parentheses of the source
+// are dropped unless they are needed.
+func (p *printer) expr(exp ast.Expression, prec int) {
+	exp = ast.Unparen(exp)
+	own := precedence(exp)
+	if own < prec {
+		p.write("(")
+		defer p.write(")")
+	}
+
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		p.write(exp.Value)
+	case *ast.IntegerLiteral:
+		p.write(exp.Token.Literal)
+	case *ast.FloatLiteral:
+		p.write(exp.Token.Literal)
+	case *ast.StringLiteral:
+		p.write(quote(exp.Value))
+	case *ast.Boolean:
+		if exp.Value {
+			p.write("true")
+		} else {
+			p.write("false")
+		}
+	case *ast.Prefix:
+		p.write(exp.Operator)
+		p.expr(exp.Right, parser.PREFIX)
+	case *ast.Infix:
+		left, right := own, own+1
+		if parser.IsRightAssoc(exp.Token.Type) {
+			left, right = own+1, own
+		}
+		p.expr(exp.Left, left)
+		p.write(" " + exp.Operator + " ")
+		p.expr(exp.Right, right)
+	case *ast.Assign:
+		p.expr(exp.Target, parser.ASSIGN+1)
+		p.write(" " + exp.Operator + " ")
+		p.expr(exp.Value, parser.ASSIGN)
+	case *ast.If:
+		p.write("if (")
+		p.expr(exp.Condition, parser.LOWEST)
+		p.write(") ")
+		p.block(exp.Consequence)
+		if exp.Alternative != nil {
+			p.write(" else ")
+			p.block(exp.Alternative)
+		}
+	case *ast.Function:
+		p.write("fn(")
+		for i, arg := range exp.Args {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.write(arg.Value)
+		}
+		p.write(") ")
+		p.block(exp.Body)
+	case *ast.Call:
+		p.expr(exp.Function, parser.CALL)
+		p.write("(")
+		p.list(exp, exp.Token.Pos, exp.Rparen, exp.Args, func(i int) {
+			p.expr(exp.Args[i], parser.LOWEST)
+		})
+		p.write(")")
+	case *ast.Index:
+		// Calls and index expressions chain without parentheses.
+		p.expr(exp.Left, parser.CALL)
+		p.write("[")
+		p.expr(exp.Index, parser.LOWEST)
+		p.write("]")
+	case *ast.ArrayLiteral:
+		p.write("[")
+		p.list(exp, exp.Token.Pos, exp.Rbrack, exp.Elements, func(i int) {
+			p.expr(exp.Elements[i], parser.LOWEST)
+		})
+		p.write("]")
+	case *ast.HashLiteral:
+		p.write("{")
+		p.list(exp, exp.Token.Pos, exp.Rbrace, exp.Keys, func(i int) {
+			p.expr(exp.Keys[i], parser.LOWEST)
+			p.write(": ")
+			p.expr(exp.Values[i], parser.LOWEST)
+		})
+		p.write("}")
+	}
+}
+
+// list prints the comma separated items of the list node enclosed
+// in the brackets at the open and close positions. The items start
+// with the exps and are printed by the item function.
+// A list with comments is printed one item per line
+// so the comments stay next to their items.
+func (p *printer) list(node ast.Node, open, close token.Position, exps []ast.Expression, item func(i int)) {
+	if !p.hasOwnComments(node, open.Offset, close.Offset) {
+		for i := range exps {
+			if i > 0 {
+				p.write(", ")
+			}
+			item(i)
+		}
+		return
+	}
+
+	p.newline()
+	p.indent++
+	p.fresh = true
+	for i, exp := range exps {
+		pos := exp.Pos()
+		p.flush(pos.Offset)
+		p.blankLine(pos)
+		item(i)
+		if i < len(exps)-1 {
+			p.write(",")
+		}
+		p.newline()
+	}
+	p.flush(close.Offset)
+	p.indent--
+}
+
+// hasOwnComments reports whether there are comments between
+// the brackets of the node at the offsets open and close.
+// Comments of the nested blocks and lists don't count
+// since they are printed along with those.
+func (p *printer) hasOwnComments(node ast.Node, open, close int) bool {
+	var nested [][2]int // The offsets of the nested brackets.
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Block:
+			nested = append(nested, [2]int{n.Token.Pos.Offset, n.Rbrace.Offset})
+		case *ast.Call:
+			nested = append(nested, [2]int{n.Token.Pos.Offset, n.Rparen.Offset})
+		case *ast.ArrayLiteral:
+			nested = append(nested, [2]int{n.Token.Pos.Offset, n.Rbrack.Offset})
+		case *ast.HashLiteral:
+			nested = append(nested, [2]int{n.Token.Pos.Offset, n.Rbrace.Offset})
+		}
+		return true
+	})
+
+next:
+	for _, comment := range p.comments[p.next:] {
+		offset := comment.Pos.Offset
+		if offset > close {
+			break
+		}
+		if offset < open {
+			continue
+		}
+		for _, b := range nested {
+			if b[0] != open && b[0] < offset && offset < b[1] {
+				continue next
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// quote returns the double quoted s escaping the characters
+// the lexer requires to be escaped.
+func quote(s string) string {
+	var buf strings.Builder
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			buf.WriteRune('\\')
+			buf.WriteRune(r)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+
+	return buf.String()
+}
@@ -0,0 +1,94 @@
+package format
+
+import (
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", ""},
+		{"let x=1", "let x = 1;\n"},
+		{"1+2*3", "1 + 2 * 3;\n"},
+		{"(1+2)*3", "(1 + 2) * 3;\n"},
+		{"1-(2-3)", "1 - (2 - 3);\n"},
+		{"(1-2)-3", "1 - 2 - 3;\n"},
+		{"2**(3**2)", "2 ** 3 ** 2;\n"},
+		{"(2**3)**2", "(2 ** 3) ** 2;\n"},
+		{"-(1+2)", "-(1 + 2);\n"},
+		{"(-2)**2", "-2 ** 2;\n"},
+		{"-(2**2)", "-(2 ** 2);\n"},
+		{"!(a==b)", "!(a == b);\n"},
+		{"a=b=1", "a = b = 1;\n"},
+		{"(a=1)+2", "(a = 1) + 2;\n"},
+		{"x+=1", "x += 1;\n"},
+		{"a[i]=f(x)[0]", "a[i] = f(x)[0];\n"},
+		{"(a+b)[0]", "(a + b)[0];\n"},
+		{"(-f)(1)", "(-f)(1);\n"},
+		{`["a\"b",1.50,true,{"k":[]}]`, `["a\"b", 1.50, true, {"k": []}];` + "\n"},
+		{`"tab\there\nnewline"`, `"tab\there\nnewline";` + "\n"},
+		{"return 1", "return 1;\n"},
+		{"let f=fn(a,b){return a+b}", "let f = fn(a, b) {\n    return a + b;\n};\n"},
+		{"let f=fn(){}", "let f = fn() {};\n"},
+		{"fn(x){x}(1)", "fn(x) {\n    x\n}(1);\n"},
+		{"if(a){b}else{c}", "if (a) {\n    b\n} else {\n    c\n}\n"},
+		{"if(a){b}\nif(c){d}", "if (a) {\n    b\n}\nif (c) {\n    d\n}\n"},
+		{"if(a){b};(c)", "if (a) {\n    b\n}\nc;\n"},
+		{"if(a){b};(c+d)*2", "if (a) {\n    b\n};\n(c + d) * 2;\n"},
+		{"if(a){b};-c", "if (a) {\n    b\n};\n-c;\n"},
+		{"if(a){b};[c][0]", "if (a) {\n    b\n};\n[c][0];\n"},
+		{"while(true){if(x){break}continue}", "while (true) {\n    if (x) {\n        break;\n    }\n    continue;\n}\n"},
+		{"for(x in [1]){puts(x);puts(x)}", "for (x in [1]) {\n    puts(x);\n    puts(x)\n}\n"},
+		{"let a=1;\n\n\n\nlet b=2;", "let a = 1;\n\nlet b = 2;\n"},
+		{"\n\nlet a=1;", "let a = 1;\n"},
+		{"let f=fn(){\n\n  1\n}", "let f = fn() {\n    1\n};\n"},
+		{"// a\n\n// b\nlet a=1; // c\n// d", "// a\n\n// b\nlet a = 1; // c\n// d\n"},
+		{"let f = fn() { // c\n  1 // d\n  // e\n}", "let f = fn() { // c\n    1 // d\n    // e\n};\n"},
+		{"while (x) {\n// todo\n}", "while (x) {\n    // todo\n}\n"},
+		{"let x = [1, // one\n2];", "let x = [\n    1, // one\n    2\n];\n"},
+		{"let arr = [\n 1, // one\n 2 // two\n];", "let arr = [\n    1, // one\n    2 // two\n];\n"},
+		{"let x = [ // first\n// one\n1,\n\n// two\n2\n// end\n];", "let x = [ // first\n    // one\n    1,\n\n    // two\n    2\n    // end\n];\n"},
+		{"let h = {\"a\": 1, // inside hash\n\"b\": [2, 3]};", "let h = {\n    \"a\": 1, // inside hash\n    \"b\": [2, 3]\n};\n"},
+		{"f(1, // one\n g(2, // two\n 3));", "f(\n    1, // one\n    g(\n        2, // two\n        3\n    )\n);\n"},
+		{"f(x, fn() { // c\n 1 });", "f(x, fn() { // c\n    1\n});\n"},
+		{"[[1, // one\n2]]", "[[\n    1, // one\n    2\n]];\n"},
+		{"f([]) // c\n", "f([]); // c\n"},
+		{"let a = 1 + // one\n2; // two", "let a = 1 + 2; // one\n// two\n"},
+	}
+
+	for _, tt := range tests {
+		got, err := Source([]byte(tt.input))
+		if err != nil {
+			t.Errorf("%q: %v", tt.input, err)
+			continue
+		}
+		if tt.want != string(got) {
+			t.Errorf("%q: expected\n%s\ngot\n%s", tt.input, tt.want, got)
+			continue
+		}
+
+		again, err := Source(got)
+		if err != nil {
+			t.Errorf("%q: %v", got, err)
+			continue
+		}
+		if string(got) != string(again) {
+			t.Errorf("%q: not idempotent, expected\n%s\ngot\n%s", tt.input, got, again)
+		}
+	}
+}
+
+func TestSourceErrors(t *testing.T) {
+	_, err := Source([]byte("let = 1; let x 2"))
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	if _, ok := err.(*Error); !ok {
+		t.Errorf("Expected *Error got %T", err)
+	}
+	if want, got := "parse error: expected token type IDENT got =; missing prefixFn for =; expected token type = got INT", err.Error(); want != got {
+		t.Errorf("Expected error %q got %q", want, got)
+	}
+}
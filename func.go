@@ -0,0 +1,124 @@
+package monkey
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/pmatseykanets/monkey/object"
+)
+
+var (
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+// RegisterFunc binds the Go function fn to the name as a builtin.
+//
+// Arguments are converted from objects to the types of fn parameters
+// with object.Decode and the result is converted back to an object
+// with object.FromGo. Variadic functions are supported.
+//
+// fn may take a context.Context as its first parameter. It receives
+// the context of the running evaluation which should be passed to Call
+// to call Monkey functions back as a part of that evaluation.
+//
+// fn may return nothing, a single value, an error or a value and an error.
+// A non-nil error and argument count or type mismatches
+// are reported as runtime errors. A runtime error returned by Call
+// is reported as is.
+func (i *Interpreter) RegisterFunc(name string, fn interface{}) error {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fmt.Errorf("%s: expected a function got %T", name, fn)
+	}
+
+	t := v.Type()
+	switch {
+	case t.NumOut() > 2:
+		return fmt.Errorf("%s: too many results %d", name, t.NumOut())
+	case t.NumOut() == 2 && t.Out(1) != errorType:
+		return fmt.Errorf("%s: second result must be error got %s", name, t.Out(1))
+	}
+
+	i.env.Set(name, &object.Builtin{Name: name, CtxFn: i.builtinFunc(name, v)})
+
+	return nil
+}
+
+// builtinFunc adapts the Go function fn to a builtin.
+func (i *Interpreter) builtinFunc(name string, fn reflect.Value) object.BuiltinContextFunction {
+	t := fn.Type()
+	// The number of parameters preceding the arguments.
+	first := 0
+	if t.NumIn() > 0 && t.In(0) == contextType {
+		first = 1
+	}
+
+	return func(ctx context.Context, args ...object.Object) object.Object {
+		numIn := t.NumIn() - first
+		if t.IsVariadic() {
+			if len(args) < numIn-1 {
+				return &object.Error{Message: fmt.Sprintf("wrong number of arguments: want at least %d, got=%d", numIn-1, len(args))}
+			}
+		} else if len(args) != numIn {
+			return &object.Error{Message: fmt.Sprintf("wrong number of arguments: want=%d, got=%d", numIn, len(args))}
+		}
+
+		in := make([]reflect.Value, first, first+len(args))
+		if first > 0 {
+			in[0] = reflect.ValueOf(&ctx).Elem()
+		}
+		for n, arg := range args {
+			var pt reflect.Type
+			if t.IsVariadic() && n >= numIn-1 {
+				pt = t.In(t.NumIn() - 1).Elem()
+			} else {
+				pt = t.In(first + n)
+			}
+
+			v := reflect.New(pt)
+			if err := object.Decode(arg, v.Interface()); err != nil {
+				return &object.Error{Message: fmt.Sprintf("argument %d to `%s`: %v", n+1, name, err)}
+			}
+			in = append(in, v.Elem())
+		}
+
+		out := i.call(ctx, fn, in)
+
+		// A trailing error result.
+		if n := len(out); n > 0 && t.Out(n-1) == errorType {
+			if err, _ := out[n-1].Interface().(error); err != nil {
+				// Runtime errors of callbacks stop the evaluation as they are.
+				if e, ok := err.(*Error); ok && e.Runtime != nil {
+					return e.Runtime
+				}
+				return &object.Error{Message: name + ": " + err.Error(), Cause: err}
+			}
+			out = out[:n-1]
+		}
+
+		if len(out) == 0 {
+			return object.NULL
+		}
+
+		result, err := object.FromGo(out[0].Interface())
+		if err != nil {
+			return &object.Error{Message: fmt.Sprintf("result of `%s`: %v", name, err)}
+		}
+
+		return result
+	}
+}
+
+// call calls the Go function fn with the args. Calls to Call made by
+// fn, e.g. with a callback passed to it, are a part of the evaluation
+// the ctx belongs to.
+func (i *Interpreter) call(ctx context.Context, fn reflect.Value, args []reflect.Value) []reflect.Value {
+	defer func(outer context.Context) {
+		i.active = outer
+	}(i.active)
+	i.active = ctx
+
+	return fn.Call(args)
+}
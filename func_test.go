@@ -0,0 +1,168 @@
+package monkey
+
+import (
+	"context"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pmatseykanets/monkey/object"
+)
+
+type point struct {
+	X, Y int
+	tag  string
+}
+
+func TestRegisterFunc(t *testing.T) {
+	funcs := map[string]interface{}{
+		"sha": func(s string) string {
+			return fmt.Sprintf("%x", sha1.Sum([]byte(s)))
+		},
+		"add":  func(a, b int64) int64 { return a + b },
+		"half": func(f float64) float64 { return f / 2 },
+		"not":  func(b bool) bool { return !b },
+		"join": func(sep string, parts ...string) string { return strings.Join(parts, sep) },
+		"sum": func(nums []int) int {
+			n := 0
+			for _, v := range nums {
+				n += v
+			}
+			return n
+		},
+		"keys":    func(m map[string]int) int { return len(m) },
+		"move":    func(p point, dx int) point { p.X += dx; return p },
+		"split":   func(s string) []string { return strings.Split(s, ",") },
+		"counts":  func() map[string]int { return map[string]int{"a": 1} },
+		"nothing": func() {},
+		"fail": func(fail bool) (int, error) {
+			if fail {
+				return 0, errors.New("failed")
+			}
+			return 1, nil
+		},
+		"check":   func() error { return nil },
+		"typeof":  func(v interface{}) string { return fmt.Sprintf("%T", v) },
+		"inspect": func(obj object.Object) string { return obj.Inspect() },
+		"byte":    func(b uint8) uint8 { return b },
+		"opt":     func(p *int) bool { return p == nil },
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`sha("abc")`, "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{`add(1, 2)`, "3"},
+		{`half(3)`, "1.5"},
+		{`half(3.0)`, "1.5"},
+		{`not(true)`, "false"},
+		{`join(", ")`, ""},
+		{`join(", ", "a", "b")`, "a, b"},
+		{`sum([1, 2, 3])`, "6"},
+		{`keys({"a": 1, "b": 2})`, "2"},
+		{`move({"X": 1, "Y": 2}, 2)["X"]`, "3"},
+		{`len(move({"X": 1, "Y": 2}, 2))`, "2"},
+		{`split("a,b")[1]`, "b"},
+		{`counts()["a"]`, "1"},
+		{`nothing()`, "null"},
+		{`fail(false)`, "1"},
+		{`check()`, "null"},
+		{`typeof(1)`, "int64"},
+		{`typeof([1, "a"])`, "[]interface {}"},
+		{`typeof({"a": 1})`, "map[string]interface {}"},
+		{`typeof({1: 1})`, "map[interface {}]interface {}"},
+		{`inspect([1])`, "[1]"},
+		{`byte(255)`, "255"},
+		{`opt(null)`, "true"},
+		{`opt(1)`, "false"},
+	}
+
+	interp := New()
+	interp.Set("null", object.NULL)
+	for name, fn := range funcs {
+		if err := interp.RegisterFunc(name, fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range tests {
+		got, err := interp.Run(context.Background(), tt.input)
+		if err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+			continue
+		}
+		if got := got.Inspect(); tt.want != got {
+			t.Errorf("%s: expected %s got %s", tt.input, tt.want, got)
+		}
+	}
+}
+
+func TestRegisterFuncErrors(t *testing.T) {
+	interp := New()
+
+	errFailed := errors.New("failed")
+	funcs := map[string]interface{}{
+		"add":  func(a, b int) int { return a + b },
+		"fail": func() (int, error) { return 0, errFailed },
+		"byte": func(b uint8) uint8 { return b },
+		"join": func(sep string, parts ...string) string { return "" },
+	}
+	for name, fn := range funcs {
+		if err := interp.RegisterFunc(name, fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		input string
+		want  string
+		cause error
+	}{
+		{`add(1)`, "runtime error: wrong number of arguments: want=2, got=1", nil},
+		{`add(1, "2")`, "runtime error: argument 2 to `add`: cannot convert STRING to int", nil},
+		{`fail()`, "runtime error: fail: failed", errFailed},
+		{`byte(256)`, "runtime error: argument 1 to `byte`: 256 overflows uint8", nil},
+		{`join()`, "runtime error: wrong number of arguments: want at least 1, got=0", nil},
+		{`join(",", 1)`, "runtime error: argument 2 to `join`: cannot convert INTEGER to string", nil},
+	}
+
+	for _, tt := range tests {
+		_, err := interp.Run(context.Background(), tt.input)
+		if err == nil {
+			t.Errorf("%s: expected error", tt.input)
+			continue
+		}
+		if got := err.Error(); tt.want != got {
+			t.Errorf("%s: expected error %q got %q", tt.input, tt.want, got)
+		}
+		if got := errors.Unwrap(err); tt.cause != got {
+			t.Errorf("%s: expected cause %v got %v", tt.input, tt.cause, got)
+		}
+	}
+}
+
+func TestRegisterFuncInvalid(t *testing.T) {
+	tests := []struct {
+		fn   interface{}
+		want string
+	}{
+		{1, "f: expected a function got int"},
+		{(func())(nil), "f: expected a function got func()"},
+		{func() (int, int) { return 0, 0 }, "f: second result must be error got int"},
+		{func() (int, int, error) { return 0, 0, nil }, "f: too many results 3"},
+	}
+
+	for _, tt := range tests {
+		err := New().RegisterFunc("f", tt.fn)
+		if err == nil {
+			t.Errorf("Expected error %q", tt.want)
+			continue
+		}
+		if got := err.Error(); tt.want != got {
+			t.Errorf("Expected error %q got %q", tt.want, got)
+		}
+	}
+}
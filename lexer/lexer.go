@@ -15,11 +15,17 @@ type Lexer struct {
 	pos   int
 	r     rune
 	err   error
+	// The position of the current rune.
+	offset int
+	line   int
+	col    int
+	// Comments skipped so far.
+	comments []token.Token
 }
 
 // New creates a new instance of Lexer.
 func New(input io.Reader) *Lexer {
-	l := &Lexer{input: bufio.NewReader(input)}
+	l := &Lexer{input: bufio.NewReader(input), line: 1}
 	return l
 }
 
@@ -29,10 +35,20 @@ func FromString(s string) *Lexer {
 }
 
 func (l *Lexer) readNext() {
+	if l.r == '\n' {
+		l.line++
+		l.col = 0
+	}
 	r, sz, err := l.input.ReadRune()
 	l.err = err
 	l.r = r
+	l.offset = l.pos
 	l.pos += sz
+	l.col++
+}
+
+func (l *Lexer) position() token.Position {
+	return token.Position{Offset: l.offset, Line: l.line, Column: l.col}
 }
 
 func (l *Lexer) peek() rune {
@@ -52,6 +68,31 @@ func (l *Lexer) peek() rune {
 	return r
 }
 
+// Comments returns the comments skipped so far
+// as tokens of type token.COMMENT.
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
+}
+
+// readComment reads a comment up to the end of the line.
+func (l *Lexer) readComment() {
+	tok := token.Token{Type: token.COMMENT, Pos: l.position()}
+
+	var s strings.Builder
+	for l.err == nil && l.r != '\n' {
+		s.WriteRune(l.r)
+		l.readNext()
+	}
+	tok.Literal = strings.TrimRightFunc(s.String(), unicode.IsSpace)
+	l.comments = append(l.comments, tok)
+}
+
+// End returns the position immediately after the last token
+// returned by NextToken.
+func (l *Lexer) End() token.Position {
+	return l.position()
+}
+
 func (l *Lexer) Error() error {
 	return l.err
 }
@@ -62,11 +103,15 @@ func (l *Lexer) NextToken() token.Token {
 		l.readNext()
 	}
 	l.skipWhitespace()
+	for l.err == nil && l.r == '/' && l.peek() == '/' {
+		l.readComment()
+		l.skipWhitespace()
+	}
 	if l.err == io.EOF {
-		return token.Token{Type: token.EOF, Literal: ""}
+		return token.Token{Type: token.EOF, Literal: "", Pos: l.position()}
 	}
 
-	tok := token.Token{Literal: string(l.r)}
+	tok := token.Token{Literal: string(l.r), Pos: l.position()}
 	switch l.r {
 	case '=':
 		if l.peek() == '=' {
@@ -85,13 +130,42 @@ func (l *Lexer) NextToken() token.Token {
 	case ',':
 		tok.Type = token.COMMA
 	case '+':
+		if l.peek() == '=' {
+			l.readNext()
+			tok.Literal = "+="
+			tok.Type = token.PLUS_ASSIGN
+			break
+		}
 		tok.Type = token.PLUS
 	case '-':
+		if l.peek() == '=' {
+			l.readNext()
+			tok.Literal = "-="
+			tok.Type = token.MINUS_ASSIGN
+			break
+		}
 		tok.Type = token.MINUS
 	case '/':
+		if l.peek() == '=' {
+			l.readNext()
+			tok.Literal = "/="
+			tok.Type = token.SLASH_ASSIGN
+			break
+		}
 		tok.Type = token.SLASH
 	case '*':
-		tok.Type = token.ASTERISK
+		switch l.peek() {
+		case '*':
+			l.readNext()
+			tok.Literal = "**"
+			tok.Type = token.POW
+		case '=':
+			l.readNext()
+			tok.Literal = "*="
+			tok.Type = token.ASTERISK_ASSIGN
+		default:
+			tok.Type = token.ASTERISK
+		}
 	case '!':
 		if l.peek() == '=' {
 			l.readNext()
@@ -108,6 +182,20 @@ func (l *Lexer) NextToken() token.Token {
 		tok.Type = token.LBRACE
 	case '}':
 		tok.Type = token.RBRACE
+	case '[':
+		tok.Type = token.LBRACKET
+	case ']':
+		tok.Type = token.RBRACKET
+	case ':':
+		tok.Type = token.COLON
+	case '"':
+		var ok bool
+		tok.Literal, ok = l.readString()
+		tok.Type = token.STRING
+		if !ok {
+			tok.Type = token.ILLEGAL
+			return tok
+		}
 	default:
 		if isLetter(l.r) {
 			tok.Literal = l.readIdent()
@@ -116,6 +204,14 @@ func (l *Lexer) NextToken() token.Token {
 		} else if unicode.IsDigit(l.r) {
 			tok.Literal = l.readNumber()
 			tok.Type = token.INT
+			if l.err == nil && l.r == '.' {
+				tok.Literal += "."
+				l.readNext()
+				if l.err == nil {
+					tok.Literal += l.readNumber()
+				}
+				tok.Type = token.FLOAT
+			}
 			return tok
 		}
 		tok.Type = token.ILLEGAL
@@ -165,3 +261,35 @@ func (l *Lexer) readNumber() string {
 	}
 	return s
 }
+
+// readString reads a double quoted string literal
+// and returns its unquoted value.
+// It reports false if the closing quote is missing.
+func (l *Lexer) readString() (string, bool) {
+	var s strings.Builder
+	for {
+		l.readNext()
+		if l.err != nil {
+			return s.String(), false
+		}
+		switch l.r {
+		case '"':
+			return s.String(), true
+		case '\\':
+			l.readNext()
+			if l.err != nil {
+				return s.String(), false
+			}
+			switch l.r {
+			case 'n':
+				s.WriteRune('\n')
+			case 't':
+				s.WriteRune('\t')
+			default:
+				s.WriteRune(l.r)
+			}
+		default:
+			s.WriteRune(l.r)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/pmatseykanets/monkey/token"
 )
 
@@ -29,6 +30,15 @@ if (5 < 10) {
 
 10 == 10;
 10 != 9;
+2 ** 3;
+3.14;
+"foobar"
+"foo bar"
+"a\"b\n"
+[1, 2];
+{"foo": "bar"}
+x += 1; x -= 1; x *= 2; x /= 2;
+while for in break continue
 `
 
 	tests := []struct {
@@ -109,14 +119,58 @@ if (5 < 10) {
 		{token.Token{Type: token.NOT_EQ, Literal: "!="}},
 		{token.Token{Type: token.INT, Literal: "9"}},
 		{token.Token{Type: token.SEMICOLON, Literal: ";"}},
+		// 2 ** 3;
+		// 3.14;
+		{token.Token{Type: token.INT, Literal: "2"}},
+		{token.Token{Type: token.POW, Literal: "**"}},
+		{token.Token{Type: token.INT, Literal: "3"}},
+		{token.Token{Type: token.SEMICOLON, Literal: ";"}},
+		{token.Token{Type: token.FLOAT, Literal: "3.14"}},
+		{token.Token{Type: token.SEMICOLON, Literal: ";"}},
+		{token.Token{Type: token.STRING, Literal: "foobar"}},
+		{token.Token{Type: token.STRING, Literal: "foo bar"}},
+		{token.Token{Type: token.STRING, Literal: "a\"b\n"}},
+		{token.Token{Type: token.LBRACKET, Literal: "["}},
+		{token.Token{Type: token.INT, Literal: "1"}},
+		{token.Token{Type: token.COMMA, Literal: ","}},
+		{token.Token{Type: token.INT, Literal: "2"}},
+		{token.Token{Type: token.RBRACKET, Literal: "]"}},
+		{token.Token{Type: token.SEMICOLON, Literal: ";"}},
+		{token.Token{Type: token.LBRACE, Literal: "{"}},
+		{token.Token{Type: token.STRING, Literal: "foo"}},
+		{token.Token{Type: token.COLON, Literal: ":"}},
+		{token.Token{Type: token.STRING, Literal: "bar"}},
+		{token.Token{Type: token.RBRACE, Literal: "}"}},
+		{token.Token{Type: token.IDENT, Literal: "x"}},
+		{token.Token{Type: token.PLUS_ASSIGN, Literal: "+="}},
+		{token.Token{Type: token.INT, Literal: "1"}},
+		{token.Token{Type: token.SEMICOLON, Literal: ";"}},
+		{token.Token{Type: token.IDENT, Literal: "x"}},
+		{token.Token{Type: token.MINUS_ASSIGN, Literal: "-="}},
+		{token.Token{Type: token.INT, Literal: "1"}},
+		{token.Token{Type: token.SEMICOLON, Literal: ";"}},
+		{token.Token{Type: token.IDENT, Literal: "x"}},
+		{token.Token{Type: token.ASTERISK_ASSIGN, Literal: "*="}},
+		{token.Token{Type: token.INT, Literal: "2"}},
+		{token.Token{Type: token.SEMICOLON, Literal: ";"}},
+		{token.Token{Type: token.IDENT, Literal: "x"}},
+		{token.Token{Type: token.SLASH_ASSIGN, Literal: "/="}},
+		{token.Token{Type: token.INT, Literal: "2"}},
+		{token.Token{Type: token.SEMICOLON, Literal: ";"}},
+		{token.Token{Type: token.WHILE, Literal: "while"}},
+		{token.Token{Type: token.FOR, Literal: "for"}},
+		{token.Token{Type: token.IN, Literal: "in"}},
+		{token.Token{Type: token.BREAK, Literal: "break"}},
+		{token.Token{Type: token.CONTINUE, Literal: "continue"}},
 		{token.Token{Type: token.EOF, Literal: ""}},
 	}
 
 	l := New(strings.NewReader(input))
+	ignorePos := cmpopts.IgnoreFields(token.Token{}, "Pos")
 
 	for i, tt := range tests {
 		got := l.NextToken()
-		if !cmp.Equal(tt.want, got) {
+		if !cmp.Equal(tt.want, got, ignorePos) {
 			t.Fatalf("[Test %d] Expected %v got %v", i, tt.want, got)
 		}
 	}
@@ -136,3 +190,72 @@ func TestLexerPeek(t *testing.T) {
 		t.Errorf("Expected read value %v got %v", want, got)
 	}
 }
+
+func TestNextTokenUnterminatedString(t *testing.T) {
+	l := FromString(`"foo`)
+
+	if want, got := (token.Token{Type: token.ILLEGAL, Literal: "foo", Pos: token.Position{Offset: 0, Line: 1, Column: 1}}), l.NextToken(); !cmp.Equal(want, got) {
+		t.Errorf("Expected %v got %v", want, got)
+	}
+	if want, got := (token.Token{Type: token.EOF, Literal: "", Pos: token.Position{Offset: 4, Line: 1, Column: 5}}), l.NextToken(); !cmp.Equal(want, got) {
+		t.Errorf("Expected %v got %v", want, got)
+	}
+}
+
+func TestNextTokenPosition(t *testing.T) {
+	input := "let x = 5;\n  \"é\" == x\n"
+
+	tests := []struct {
+		typ token.TokenType
+		pos token.Position
+		end token.Position
+	}{
+		{token.LET, token.Position{Offset: 0, Line: 1, Column: 1}, token.Position{Offset: 3, Line: 1, Column: 4}},
+		{token.IDENT, token.Position{Offset: 4, Line: 1, Column: 5}, token.Position{Offset: 5, Line: 1, Column: 6}},
+		{token.ASSIGN, token.Position{Offset: 6, Line: 1, Column: 7}, token.Position{Offset: 7, Line: 1, Column: 8}},
+		{token.INT, token.Position{Offset: 8, Line: 1, Column: 9}, token.Position{Offset: 9, Line: 1, Column: 10}},
+		{token.SEMICOLON, token.Position{Offset: 9, Line: 1, Column: 10}, token.Position{Offset: 10, Line: 1, Column: 11}},
+		{token.STRING, token.Position{Offset: 13, Line: 2, Column: 3}, token.Position{Offset: 17, Line: 2, Column: 6}},
+		{token.EQ, token.Position{Offset: 18, Line: 2, Column: 7}, token.Position{Offset: 20, Line: 2, Column: 9}},
+		{token.IDENT, token.Position{Offset: 21, Line: 2, Column: 10}, token.Position{Offset: 22, Line: 2, Column: 11}},
+		{token.EOF, token.Position{Offset: 23, Line: 3, Column: 1}, token.Position{Offset: 23, Line: 3, Column: 1}},
+	}
+
+	l := FromString(input)
+	for i, tt := range tests {
+		got := l.NextToken()
+		if want, got := tt.typ, got.Type; want != got {
+			t.Fatalf("[Test %d] Expected type %s got %s", i, want, got)
+		}
+		if want, got := tt.pos, got.Pos; want != got {
+			t.Errorf("[Test %d] Expected position %+v got %+v", i, want, got)
+		}
+		if want, got := tt.end, l.End(); want != got {
+			t.Errorf("[Test %d] Expected end %+v got %+v", i, want, got)
+		}
+	}
+}
+
+func TestNextTokenComments(t *testing.T) {
+	input := "// leading\nlet x = 5; // trailing  \n// last"
+
+	l := FromString(input)
+	var types []token.TokenType
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		types = append(types, tok.Type)
+	}
+
+	wantTypes := []token.TokenType{token.LET, token.IDENT, token.ASSIGN, token.INT, token.SEMICOLON}
+	if !cmp.Equal(wantTypes, types) {
+		t.Errorf("Expected types %v got %v", wantTypes, types)
+	}
+
+	want := []token.Token{
+		{Type: token.COMMENT, Literal: "// leading", Pos: token.Position{Offset: 0, Line: 1, Column: 1}},
+		{Type: token.COMMENT, Literal: "// trailing", Pos: token.Position{Offset: 22, Line: 2, Column: 12}},
+		{Type: token.COMMENT, Literal: "// last", Pos: token.Position{Offset: 36, Line: 3, Column: 1}},
+	}
+	if diff := cmp.Diff(want, l.Comments()); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
@@ -0,0 +1,176 @@
+// Package lint implements a linter for Monkey programs.
+//
+// The linter runs a set of rules over the syntax tree of a program.
+// Each rule reports diagnostics with its own ID and severity, and
+// may suggest a fix for each of them. Rules are plain values so
+// programs embedding the linter can add their own.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/pmatseykanets/monkey/ast"
+	"github.com/pmatseykanets/monkey/eval"
+	"github.com/pmatseykanets/monkey/resolve"
+	"github.com/pmatseykanets/monkey/token"
+)
+
+// Severity is the severity of a diagnostic.
+type Severity int
+
+// Severities of diagnostics.
+const (
+	Info    Severity = iota // A matter of style.
+	Warning                 // Likely a mistake.
+	Error                   // Fails at runtime.
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Rule is a lint rule.
+type Rule struct {
+	ID       string // A unique name in kebab case, e.g. unused-let.
+	Doc      string // A one line description.
+	Severity Severity
+	Check    func(*Pass) // Check reports the problems found in the pass.
+}
+
+// Diagnostic describes a problem found by a rule.
+type Diagnostic struct {
+	Rule     string // The ID of the rule.
+	Severity Severity
+	Pos      token.Position
+	End      token.Position
+	Message  string
+	Fix      *Fix // The suggested fix or nil.
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", d.Pos, d.Severity, d.Message, d.Rule)
+}
+
+// Fix is a suggested fix replacing the source
+// between Pos and End with the Text.
+type Fix struct {
+	Message string
+	Pos     token.Position
+	End     token.Position
+	Text    string
+}
+
+// Pass holds the program checked by a rule.
+type Pass struct {
+	Program *ast.Program
+	// Info holds the bindings of the identifiers of the program.
+	Info *resolve.Info
+	// Resolved holds the diagnostics of the resolver.
+	Resolved []resolve.Diagnostic
+	// Predeclared holds the names of the builtins and the globals.
+	Predeclared map[string]bool
+
+	rule  *Rule
+	diags []Diagnostic
+}
+
+// Report reports the diagnostic setting its rule and severity.
+func (p *Pass) Report(d Diagnostic) {
+	d.Rule, d.Severity = p.rule.ID, p.rule.Severity
+	p.diags = append(p.diags, d)
+}
+
+// Reportf reports a diagnostic spanning the node.
+func (p *Pass) Reportf(node ast.Node, format string, a ...interface{}) {
+	p.Report(Diagnostic{Pos: node.Pos(), End: node.End(), Message: fmt.Sprintf(format, a...)})
+}
+
+// Check checks the program with the rules and returns the diagnostics
+// ordered by position. The globals are the names bound in the global
+// environment outside of the program, e.g. with Interpreter.Set.
+// Check resolves the identifiers of the program.
+func Check(prg *ast.Program, rules []*Rule, globals ...string) []Diagnostic {
+	info := &resolve.Info{}
+	pass := &Pass{
+		Program:     prg,
+		Info:        info,
+		Resolved:    resolve.Resolve(prg, resolve.WithGlobals(globals...), resolve.WithInfo(info)),
+		Predeclared: make(map[string]bool),
+	}
+	for _, name := range eval.BuiltinNames() {
+		pass.Predeclared[name] = true
+	}
+	for _, name := range globals {
+		pass.Predeclared[name] = true
+	}
+
+	for _, rule := range rules {
+		pass.rule = rule
+		rule.Check(pass)
+	}
+
+	sort.SliceStable(pass.diags, func(i, j int) bool {
+		return pass.diags[i].Pos.Offset < pass.diags[j].Pos.Offset
+	})
+
+	return pass.diags
+}
+
+// Apply applies the fixes of the diagnostics to the src and returns
+// the result and the number of fixes applied. Fixes overlapping
+// the ones applied before them are skipped.
+func Apply(src []byte, diags []Diagnostic) ([]byte, int) {
+	var fixes []*Fix
+	for _, d := range diags {
+		if d.Fix != nil && d.Fix.Pos.IsValid() && d.Fix.End.IsValid() {
+			fixes = append(fixes, d.Fix)
+		}
+	}
+	sort.SliceStable(fixes, func(i, j int) bool {
+		return fixes[i].Pos.Offset < fixes[j].Pos.Offset
+	})
+
+	var (
+		out     []byte
+		last    int // The offset of the source not copied yet.
+		applied int
+	)
+	for _, fix := range fixes {
+		if fix.Pos.Offset < last || fix.End.Offset < fix.Pos.Offset || fix.End.Offset > len(src) {
+			continue
+		}
+		out = append(out, src[last:fix.Pos.Offset]...)
+		out = append(out, fix.Text...)
+		last = fix.End.Offset
+		applied++
+	}
+	out = append(out, src[last:]...)
+
+	return out, applied
+}
+
+// identEnd returns the position after the identifier with the name at pos.
+func identEnd(pos token.Position, name string) token.Position {
+	return token.Position{
+		Offset: pos.Offset + len(name),
+		Line:   pos.Line,
+		Column: pos.Column + utf8.RuneCountInString(name),
+	}
+}
+
+// lineStart returns the position of the first character of the line
+// of pos which must be preceded only by whitespace on its line.
+func lineStart(pos token.Position) token.Position {
+	return token.Position{Offset: pos.Offset - pos.Column + 1, Line: pos.Line, Column: 1}
+}
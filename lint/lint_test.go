@@ -0,0 +1,180 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pmatseykanets/monkey/ast"
+	"github.com/pmatseykanets/monkey/lexer"
+	"github.com/pmatseykanets/monkey/parser"
+	"github.com/pmatseykanets/monkey/token"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.FromString(input))
+	prg := p.Parse()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("%q: parse errors %v", input, errs)
+	}
+
+	return prg
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		rule  *Rule
+		input string
+		want  []string
+	}{
+		{UndefinedName, "let x = 1; x + len([])", nil},
+		{UndefinedName, "lenght([])", []string{"1:1: error: undefined: lenght (undefined-name)"}},
+		{DuplicateParam, "fn(a, b, a) { a + b }", []string{"1:10: error: duplicate parameter a (duplicate-param)"}},
+
+		{UnusedLet, "let x = 1;", nil},
+		{UnusedLet, "fn() { let x = 1; x }", nil},
+		{UnusedLet, "fn() { let x = 1; x = 2; }", nil},
+		{UnusedLet, "fn() { let _x = 1; }", nil},
+		{UnusedLet, "fn() { let f = fn() { f() }; f }", nil},
+		{UnusedLet, "fn() { let x = 1; let x = 2; x }", nil},
+		{UnusedLet, "fn() { let x = 1; }", []string{"1:12: warning: x declared and not used (unused-let)"}},
+		{UnusedLet, "for (i in 3) { let sq = i * i; }", []string{"1:20: warning: sq declared and not used (unused-let)"}},
+		{
+			UnusedLet,
+			"let x = 1; fn() { if (true) { let x = 2; } x }; fn() { let x = 3; }",
+			[]string{"1:60: warning: x declared and not used (unused-let)"},
+		},
+
+		{UnusedParam, "fn(a, b) { a + b }", nil},
+		{UnusedParam, "fn(a, _b) { a }", nil},
+		{UnusedParam, "fn(a) { fn() { a } }", nil},
+		{UnusedParam, "fn(a, b) { a }", []string{"1:7: info: unused parameter b (unused-param)"}},
+		{UnusedParam, "fn(a) { fn(a) { a } }", []string{"1:4: info: unused parameter a (unused-param)"}},
+
+		{UnreachableCode, "fn() { return 1; }", nil},
+		{UnreachableCode, "fn() { if (true) { return 1; }; 2 }", nil},
+		{UnreachableCode, "fn() { return 1; 2; 3 }", []string{"1:18: warning: unreachable code (unreachable-code)"}},
+		{UnreachableCode, "for (i in 3) { continue; puts(i); }", []string{"1:26: warning: unreachable code (unreachable-code)"}},
+		{UnreachableCode, "while (true) { break; 1 }", []string{"1:23: warning: unreachable code (unreachable-code)"}},
+		{UnreachableCode, "return 1; 2", []string{"1:11: warning: unreachable code (unreachable-code)"}},
+
+		{SelfComparison, "let x = 1; let y = 2; x == y", nil},
+		{SelfComparison, "let f = fn() { 1 }; f() == f()", nil},
+		{SelfComparison, "[1] == [1]", nil},
+		{SelfComparison, "let x = 1; x + x", nil},
+		{SelfComparison, "let x = 1; x == x", []string{"1:12: warning: x compared with itself (self-comparison)"}},
+		{SelfComparison, "let a = [1]; a[0] != a[0]", []string{"1:14: warning: (a[0]) compared with itself (self-comparison)"}},
+
+		{ConstantCondition, "let x = 1; if (x > 0) { 1 }", nil},
+		{ConstantCondition, "if (1 / 0) { 1 }", nil},
+		{ConstantCondition, "while (true) { break; }", nil},
+		{ConstantCondition, "if (true) { 1 }", []string{"1:5: warning: condition is always true (constant-condition)"}},
+		{ConstantCondition, "if (1 > 2) { 1 }", []string{"1:5: warning: condition is always false (constant-condition)"}},
+		{ConstantCondition, `if ("") { 1 }`, []string{"1:5: warning: condition is always true (constant-condition)"}},
+
+		{ShadowedBuiltin, "let length = len;", nil},
+		{ShadowedBuiltin, "let len = 1;", []string{"1:5: warning: len shadows a predeclared name (shadowed-builtin)"}},
+		{ShadowedBuiltin, "fn(push) { push }", []string{"1:4: warning: push shadows a predeclared name (shadowed-builtin)"}},
+		{ShadowedBuiltin, "for (len in 3) { len }", []string{"1:6: warning: len shadows a predeclared name (shadowed-builtin)"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, d := range Check(parse(t, tt.input), []*Rule{tt.rule}) {
+			got = append(got, d.String())
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s %q: unexpected diagnostics (-want +got):\n%s", tt.rule.ID, tt.input, diff)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	input := "let f = fn(len, b) {\n  return len + c;\n  b\n};\nf == f"
+	want := []string{
+		"1:12: warning: len shadows a predeclared name (shadowed-builtin)",
+		"2:16: error: undefined: c (undefined-name)",
+		"3:3: warning: unreachable code (unreachable-code)",
+		"5:1: warning: f compared with itself (self-comparison)",
+	}
+
+	var got []string
+	for _, d := range Check(parse(t, input), Rules) {
+		got = append(got, d.String())
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected diagnostics (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckGlobals(t *testing.T) {
+	prg := parse(t, "puts(args); let exit = 1;")
+
+	want := []Diagnostic{
+		{
+			Rule:     "shadowed-builtin",
+			Severity: Warning,
+			Pos:      token.Position{Offset: 16, Line: 1, Column: 17},
+			End:      token.Position{Offset: 20, Line: 1, Column: 21},
+			Message:  "exit shadows a predeclared name",
+		},
+	}
+	if diff := cmp.Diff(want, Check(prg, Rules, "puts", "args", "exit")); diff != "" {
+		t.Errorf("Unexpected diagnostics (-want +got):\n%s", diff)
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		fixes int
+	}{
+		{"fn() { 1 }", "fn() { 1 }", 0},
+		{"fn() { return 1; 2; (3) }", "fn() { return 1; }", 1},
+		{"fn() {\n  return 1;\n  2\n}", "fn() {\n  return 1;\n}", 1},
+		// Comments before the unreachable code are kept.
+		{"fn() {\n  return 1;\n  // Done.\n  2;\n  (3)\n}", "fn() {\n  return 1;\n  // Done.\n}", 1},
+		{"fn() {\n  return 1;\n  2 }", "fn() {\n  return 1;\n}", 1},
+		{"fn() {\n  return 1; 2\n}", "fn() {\n  return 1; }", 1},
+		{
+			"fn() {\n\tfor (i in 3) {\n\t\tbreak;\n\t\ti\n\t}\n}",
+			"fn() {\n\tfor (i in 3) {\n\t\tbreak;\n\t}\n}",
+			1,
+		},
+		{
+			"fn() { for (i in 3) { break; i } return 1; 2 }",
+			"fn() { for (i in 3) { break; } return 1; }",
+			2,
+		},
+		// The fix of the inner block overlaps the outer one.
+		{"fn() { return 1; fn() { return 2; 3 } }", "fn() { return 1; }", 1},
+		// Statements of the program are not fixed.
+		{"return 1; 2", "return 1; 2", 0},
+	}
+
+	for _, tt := range tests {
+		src := []byte(tt.input)
+		got, fixes := Apply(src, Check(parse(t, tt.input), Rules))
+		if string(got) != tt.want {
+			t.Errorf("%q: expected %q got %q", tt.input, tt.want, got)
+		}
+		if fixes != tt.fixes {
+			t.Errorf("%q: expected %d fixes got %d", tt.input, tt.fixes, fixes)
+		}
+	}
+}
+
+func TestRuleIDs(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, rule := range Rules {
+		if seen[rule.ID] {
+			t.Errorf("Expected unique rule IDs got %s twice", rule.ID)
+		}
+		seen[rule.ID] = true
+		if rule.Doc == "" || rule.Check == nil {
+			t.Errorf("Expected %s to have a doc and a check", rule.ID)
+		}
+	}
+}
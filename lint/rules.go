@@ -0,0 +1,321 @@
+package lint
+
+import (
+	"strings"
+
+	"github.com/pmatseykanets/monkey/ast"
+	"github.com/pmatseykanets/monkey/eval"
+	"github.com/pmatseykanets/monkey/object"
+	"github.com/pmatseykanets/monkey/resolve"
+)
+
+// Rules holds the rules of the linter in the order they run.
+var Rules = []*Rule{
+	UndefinedName,
+	DuplicateParam,
+	UnusedLet,
+	UnusedParam,
+	UnreachableCode,
+	SelfComparison,
+	ConstantCondition,
+	ShadowedBuiltin,
+}
+
+// UndefinedName reports names bound neither by the program
+// nor predeclared.
+var UndefinedName = &Rule{
+	ID:       "undefined-name",
+	Doc:      "names must be bound before they are used",
+	Severity: Error,
+	Check: func(p *Pass) {
+		reportResolved(p, resolve.Undefined)
+	},
+}
+
+// DuplicateParam reports functions with several parameters
+// with the same name.
+var DuplicateParam = &Rule{
+	ID:       "duplicate-param",
+	Doc:      "parameters of a function must have distinct names",
+	Severity: Error,
+	Check: func(p *Pass) {
+		reportResolved(p, resolve.DuplicateParameter)
+	},
+}
+
+func reportResolved(p *Pass, kind resolve.Kind) {
+	for _, d := range p.Resolved {
+		if d.Kind == kind {
+			p.Report(Diagnostic{Pos: d.Pos, End: identEnd(d.Pos, d.Name), Message: d.Message})
+		}
+	}
+}
+
+// UnusedLet reports let bindings in functions and for loops which
+// are never used. Global bindings may be used outside of the program,
+// e.g. with Interpreter.Get, and are not reported.
+// Names starting with _ are never reported.
+var UnusedLet = &Rule{
+	ID:       "unused-let",
+	Doc:      "local let bindings should be used",
+	Severity: Warning,
+	Check: func(p *Pass) {
+		used := usedBindings(p)
+		inspectScopes(p.Program, func(n ast.Node, local bool) {
+			if let, ok := n.(*ast.Let); ok && local && !isUsed(p, used, let.Name) {
+				p.Reportf(let.Name, "%s declared and not used", let.Name.Value)
+			}
+		})
+	},
+}
+
+// UnusedParam reports function parameters which are never used.
+// Names starting with _ are never reported.
+var UnusedParam = &Rule{
+	ID:       "unused-param",
+	Doc:      "function parameters should be used",
+	Severity: Info,
+	Check: func(p *Pass) {
+		used := usedBindings(p)
+		ast.Inspect(p.Program, func(n ast.Node) bool {
+			if fn, ok := n.(*ast.Function); ok {
+				for _, arg := range fn.Args {
+					if p.Info.Uses[arg] == arg && !isUsed(p, used, arg) {
+						p.Reportf(arg, "unused parameter %s", arg.Value)
+					}
+				}
+			}
+			return true
+		})
+	},
+}
+
+// usedBindings returns the bindings referred to by
+// identifiers other than the names they bind.
+func usedBindings(p *Pass) map[*ast.Identifier]bool {
+	decls := make(map[*ast.Identifier]bool)
+	ast.Inspect(p.Program, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Let:
+			decls[n.Name] = true
+		case *ast.Function:
+			for _, arg := range n.Args {
+				decls[arg] = true
+			}
+		case *ast.For:
+			decls[n.Var] = true
+		}
+		return true
+	})
+
+	used := make(map[*ast.Identifier]bool)
+	for ident, binding := range p.Info.Uses {
+		if !decls[ident] {
+			used[binding] = true
+		}
+	}
+
+	return used
+}
+
+// isUsed reports whether the binding of the name is used.
+func isUsed(p *Pass, used map[*ast.Identifier]bool, name *ast.Identifier) bool {
+	return strings.HasPrefix(name.Value, "_") || used[p.Info.Uses[name]]
+}
+
+// inspectScopes inspects the nodes of the tree reporting
+// whether they are in a function or a for loop.
+func inspectScopes(node ast.Node, f func(n ast.Node, local bool)) {
+	var (
+		stack []bool // Whether the nodes on the path open a scope.
+		depth int    // The number of scopes opened.
+	)
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == nil {
+			if stack[len(stack)-1] {
+				depth--
+			}
+			stack = stack[:len(stack)-1]
+			return true
+		}
+
+		f(n, depth > 0)
+
+		switch n.(type) {
+		case *ast.Function, *ast.For:
+			depth++
+			stack = append(stack, true)
+		default:
+			stack = append(stack, false)
+		}
+		return true
+	})
+}
+
+// UnreachableCode reports statements following a return,
+// break or continue statement in the same block.
+// The fix removes the statements from blocks.
+var UnreachableCode = &Rule{
+	ID:       "unreachable-code",
+	Doc:      "statements after return, break or continue are never run",
+	Severity: Warning,
+	Check: func(p *Pass) {
+		ast.Inspect(p.Program, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.Program:
+				checkUnreachable(p, n.Statements, nil)
+			case *ast.Block:
+				checkUnreachable(p, n.Statements, n)
+			}
+			return true
+		})
+	},
+}
+
+// checkUnreachable reports the statements of the list following
+// a return, break or continue statement. The list belongs to the
+// block or to the program if the block is nil.
+func checkUnreachable(p *Pass, list []ast.Statement, block *ast.Block) {
+	for i, stmt := range list {
+		switch stmt.(type) {
+		case *ast.Return, *ast.Break, *ast.Continue:
+		default:
+			continue
+		}
+		if i == len(list)-1 {
+			return
+		}
+
+		d := Diagnostic{
+			Pos:     list[i+1].Pos(),
+			End:     list[len(list)-1].End(),
+			Message: "unreachable code",
+		}
+		if block != nil {
+			d.Fix = &Fix{Message: "remove the unreachable code", Pos: d.Pos, End: block.Rbrace}
+			// Statements starting a line of their own are removed
+			// together with their indentation, and so is the line
+			// break before the closing brace on a line of its own.
+			if d.Pos.Line > stmt.End().Line {
+				d.Fix.Pos = lineStart(d.Pos)
+				if block.Rbrace.Line > d.End.Line {
+					d.Fix.End = lineStart(block.Rbrace)
+				}
+			}
+		}
+		p.Report(d)
+		return
+	}
+}
+
+// SelfComparison reports comparisons of expressions with themselves
+// which are either always or never true.
+var SelfComparison = &Rule{
+	ID:       "self-comparison",
+	Doc:      "values should not be compared with themselves",
+	Severity: Warning,
+	Check: func(p *Pass) {
+		ast.Inspect(p.Program, func(n ast.Node) bool {
+			exp, ok := n.(*ast.Infix)
+			if !ok {
+				return true
+			}
+			switch exp.Operator {
+			case "==", "!=", "<", ">":
+				if isPure(exp.Left) && ast.Equal(exp.Left, exp.Right) {
+					p.Reportf(exp, "%s compared with itself", exp.Left)
+				}
+			}
+			return true
+		})
+	},
+}
+
+// isPure reports whether the expression evaluates to the same
+// value every time without side effects. Array, hash and function
+// literals are not pure as they create a new value every time.
+func isPure(exp ast.Expression) bool {
+	pure := true
+	ast.Inspect(exp, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil, *ast.Identifier, *ast.Index, *ast.Prefix, *ast.Infix, *ast.Paren,
+			*ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.Boolean:
+		default:
+			pure = false
+		}
+		return pure
+	})
+
+	return pure
+}
+
+// ConstantCondition reports if expressions whose condition
+// consists only of literals and operators.
+var ConstantCondition = &Rule{
+	ID:       "constant-condition",
+	Doc:      "conditions of if expressions should not be constant",
+	Severity: Warning,
+	Check: func(p *Pass) {
+		ast.Inspect(p.Program, func(n ast.Node) bool {
+			exp, ok := n.(*ast.If)
+			if !ok || !isConstant(exp.Condition) {
+				return true
+			}
+
+			switch result := eval.Eval(exp.Condition, object.NewEnvironment()); result {
+			case object.NULL, object.FALSE:
+				p.Reportf(exp.Condition, "condition is always false")
+			default:
+				if _, ok := result.(*object.Error); !ok {
+					p.Reportf(exp.Condition, "condition is always true")
+				}
+			}
+			return true
+		})
+	},
+}
+
+// isConstant reports whether the expression
+// consists only of literals and operators.
+func isConstant(exp ast.Expression) bool {
+	constant := true
+	ast.Inspect(exp, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil, *ast.Prefix, *ast.Infix, *ast.Paren,
+			*ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.Boolean:
+		default:
+			constant = false
+		}
+		return constant
+	})
+
+	return constant
+}
+
+// ShadowedBuiltin reports let bindings, parameters and loop
+// variables with the names of builtins or globals.
+var ShadowedBuiltin = &Rule{
+	ID:       "shadowed-builtin",
+	Doc:      "bindings should not shadow builtins",
+	Severity: Warning,
+	Check: func(p *Pass) {
+		report := func(ident *ast.Identifier) {
+			if p.Predeclared[ident.Value] {
+				p.Reportf(ident, "%s shadows a predeclared name", ident.Value)
+			}
+		}
+		ast.Inspect(p.Program, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.Let:
+				report(n.Name)
+			case *ast.Function:
+				for _, arg := range n.Args {
+					report(arg)
+				}
+			case *ast.For:
+				report(n.Var)
+			}
+			return true
+		})
+	},
+}
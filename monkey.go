@@ -0,0 +1,246 @@
+// Package monkey embeds the Monkey language interpreter into Go programs.
+//
+// A minimal example:
+//
+//	interp := monkey.New()
+//	result, err := interp.Run(ctx, `let x = 2; x * 21`)
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(result.Inspect())
+package monkey
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pmatseykanets/monkey/ast"
+	"github.com/pmatseykanets/monkey/eval"
+	"github.com/pmatseykanets/monkey/lexer"
+	"github.com/pmatseykanets/monkey/object"
+	"github.com/pmatseykanets/monkey/parser"
+	"github.com/pmatseykanets/monkey/resolve"
+)
+
+// Interpreter runs Monkey scripts.
+// Bindings made by a script persist across runs.
+// An Interpreter is not safe for concurrent use.
+type Interpreter struct {
+	env    *object.Environment
+	stdout io.Writer
+	stderr io.Writer
+	opts   []eval.Option
+	active context.Context // The context of the evaluation calling a registered function.
+}
+
+// Option configures an Interpreter.
+type Option func(*Interpreter)
+
+// WithStdout sets the writer the puts builtin prints to.
+// It defaults to os.Stdout.
+func WithStdout(w io.Writer) Option {
+	return func(i *Interpreter) {
+		i.stdout = w
+	}
+}
+
+// WithStderr sets the writer the eputs builtin prints to.
+// It defaults to os.Stderr.
+func WithStderr(w io.Writer) Option {
+	return func(i *Interpreter) {
+		i.stderr = w
+	}
+}
+
+// WithEvalOptions sets options applied to every run,
+// e.g. eval.WithMaxSteps or eval.WithTimeout.
+func WithEvalOptions(opts ...eval.Option) Option {
+	return func(i *Interpreter) {
+		i.opts = append(i.opts, opts...)
+	}
+}
+
+// New creates a new instance of Interpreter.
+func New(opts ...Option) *Interpreter {
+	i := &Interpreter{
+		env:    object.NewEnvironment(),
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	i.env.Set("puts", &object.Builtin{Name: "puts", Fn: puts(i.stdout)})
+	i.env.Set("eputs", &object.Builtin{Name: "eputs", Fn: puts(i.stderr)})
+
+	return i
+}
+
+// puts returns a builtin printing its arguments one per line to w.
+func puts(w io.Writer) object.BuiltinFunction {
+	return func(args ...object.Object) object.Object {
+		for _, arg := range args {
+			fmt.Fprintln(w, arg.Inspect())
+		}
+
+		return object.NULL
+	}
+}
+
+// Run parses and evaluates the src returning the value
+// of the last statement.
+// The returned error, if any, is of type *Error.
+func (i *Interpreter) Run(ctx context.Context, src string) (object.Object, error) {
+	return i.run(ctx, "", src)
+}
+
+// RunFile reads the script from the file at path and runs it.
+// The script may start with a #! line which is ignored.
+func (i *Interpreter) RunFile(ctx context.Context, path string) (object.Object, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.run(ctx, path, stripShebang(string(src)))
+}
+
+// stripShebang blanks out the #! line the src starts with, if any,
+// preserving line numbers.
+func stripShebang(src string) string {
+	if !strings.HasPrefix(src, "#!") {
+		return src
+	}
+	if n := strings.IndexByte(src, '\n'); n >= 0 {
+		return src[n:]
+	}
+
+	return ""
+}
+
+func (i *Interpreter) run(ctx context.Context, file, src string) (object.Object, error) {
+	p := parser.New(lexer.FromString(src))
+	prg := p.Parse()
+	if len(p.Errors()) > 0 {
+		return nil, &Error{File: file, Parse: p.Errors()}
+	}
+
+	result, err := i.eval(ctx, file, prg)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return object.NULL, nil
+	}
+
+	return result, nil
+}
+
+// Eval evaluates the parsed program the same way Run does
+// except that it returns nil if the program has no value,
+// e.g. if it ends with a let statement.
+// The returned error, if any, is of type *Error.
+func (i *Interpreter) Eval(ctx context.Context, prg *ast.Program) (object.Object, error) {
+	return i.eval(ctx, "", prg)
+}
+
+func (i *Interpreter) eval(ctx context.Context, file string, prg *ast.Program) (object.Object, error) {
+	// Resolving the identifiers speeds up their lookup.
+	// Undefined names are reported at runtime.
+	resolve.Resolve(prg)
+
+	result := eval.EvalContext(ctx, prg, i.env, i.opts...)
+	if err, ok := result.(*object.Error); ok {
+		return nil, &Error{File: file, Runtime: err}
+	}
+
+	return result, nil
+}
+
+// Call calls the Monkey function or builtin fn, e.g. a callback
+// obtained with Get or passed to a registered Go function,
+// with the args converted by object.FromGo.
+// It can be called after the run that created fn has returned.
+//
+// Called from a registered Go function, the call is a part of the
+// evaluation calling that function: it shares its call depth, step
+// budget, memory quota and context, so recursion through Go functions
+// is bounded by eval.WithMaxDepth. The ctx is ignored in that case.
+//
+// The returned runtime error, if any, is of type *Error.
+func (i *Interpreter) Call(ctx context.Context, fn object.Object, args ...interface{}) (object.Object, error) {
+	if i.active != nil {
+		ctx = i.active
+	}
+
+	objs := make([]object.Object, len(args))
+	for n, arg := range args {
+		obj, err := object.FromGo(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %v", n+1, err)
+		}
+		objs[n] = obj
+	}
+
+	result := eval.ApplyContext(ctx, fn, objs, i.opts...)
+	if err, ok := result.(*object.Error); ok {
+		return nil, &Error{Runtime: err}
+	}
+
+	return result, nil
+}
+
+// Set binds the name to the val in the global environment.
+func (i *Interpreter) Set(name string, val object.Object) {
+	i.env.Set(name, val)
+}
+
+// Get looks up the value bound to the name in the global environment.
+func (i *Interpreter) Get(name string) (object.Object, bool) {
+	return i.env.Get(name)
+}
+
+// Names returns the sorted names bound in the global environment.
+func (i *Interpreter) Names() []string {
+	return i.env.Names()
+}
+
+// Error describes a failure to parse or evaluate a script.
+// Exactly one of Parse and Runtime is set.
+type Error struct {
+	File    string        // The name of the script file, if any.
+	Parse   []error       // Errors reported by the parser.
+	Runtime *object.Error // The error the evaluation ended with.
+}
+
+func (e *Error) Error() string {
+	var prefix string
+	if e.File != "" {
+		prefix = e.File + ": "
+	}
+
+	if len(e.Parse) > 0 {
+		msgs := make([]string, len(e.Parse))
+		for i := range e.Parse {
+			msgs[i] = e.Parse[i].Error()
+		}
+		return prefix + "parse error: " + strings.Join(msgs, "; ")
+	}
+
+	return prefix + "runtime error: " + e.Runtime.Message
+}
+
+// Unwrap returns the Go error that caused the runtime error, if any.
+// E.g. context.DeadlineExceeded or eval.ErrStepBudget.
+func (e *Error) Unwrap() error {
+	if e.Runtime == nil {
+		return nil
+	}
+
+	return e.Runtime.Cause
+}
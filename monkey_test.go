@@ -0,0 +1,318 @@
+package monkey
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pmatseykanets/monkey/ast"
+	"github.com/pmatseykanets/monkey/eval"
+	"github.com/pmatseykanets/monkey/lexer"
+	"github.com/pmatseykanets/monkey/object"
+	"github.com/pmatseykanets/monkey/parser"
+)
+
+func TestInterpreterRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	interp := New(WithStdout(&stdout), WithStderr(&stderr))
+
+	got, err := interp.Run(context.Background(), `let x = 2; puts("hello", x); eputs("oops"); x * 21`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "42", got.Inspect(); want != got {
+		t.Errorf("Expected result %s got %s", want, got)
+	}
+	if want, got := "hello\n2\n", stdout.String(); want != got {
+		t.Errorf("Expected stdout %q got %q", want, got)
+	}
+	if want, got := "oops\n", stderr.String(); want != got {
+		t.Errorf("Expected stderr %q got %q", want, got)
+	}
+
+	// Bindings persist across runs.
+	got, err = interp.Run(context.Background(), "x + 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "3", got.Inspect(); want != got {
+		t.Errorf("Expected result %s got %s", want, got)
+	}
+
+	got, err = interp.Run(context.Background(), "let y = 1;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != eval.NULL {
+		t.Errorf("Expected NULL got %v", got)
+	}
+}
+
+func TestInterpreterSetGet(t *testing.T) {
+	interp := New()
+	interp.Set("answer", &object.Integer{Value: 41})
+
+	if _, err := interp.Run(context.Background(), "let result = answer + 1;"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := interp.Get("result")
+	if !ok {
+		t.Fatal("Expected result to be bound")
+	}
+	if want, got := "42", got.Inspect(); want != got {
+		t.Errorf("Expected result %s got %s", want, got)
+	}
+
+	if _, ok := interp.Get("missing"); ok {
+		t.Error("Expected missing not to be bound")
+	}
+}
+
+func TestInterpreterEval(t *testing.T) {
+	var stdout bytes.Buffer
+	interp := New(WithStdout(&stdout))
+
+	parse := func(src string) *ast.Program {
+		p := parser.New(lexer.FromString(src))
+		prg := p.Parse()
+		if errs := p.Errors(); len(errs) > 0 {
+			t.Fatalf("%q: parse errors %v", src, errs)
+		}
+		return prg
+	}
+
+	got, err := interp.Eval(context.Background(), parse(`let x = 2; puts(x);`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != object.NULL {
+		t.Errorf("Expected NULL got %v", got)
+	}
+	if want, got := "2\n", stdout.String(); want != got {
+		t.Errorf("Expected stdout %q got %q", want, got)
+	}
+
+	// Programs without a value evaluate to nil.
+	if got, err := interp.Eval(context.Background(), parse("let y = x;")); got != nil || err != nil {
+		t.Errorf("Expected nil, nil got %v, %v", got, err)
+	}
+
+	_, err = interp.Eval(context.Background(), parse("z"))
+	if want := "runtime error: identifier not found: z"; err == nil || err.Error() != want {
+		t.Errorf("Expected error %q got %v", want, err)
+	}
+
+	if diff := cmp.Diff([]string{"eputs", "puts", "x", "y"}, interp.Names()); diff != "" {
+		t.Errorf("Unexpected names (-want +got):\n%s", diff)
+	}
+}
+
+func TestInterpreterCall(t *testing.T) {
+	interp := New()
+
+	var handlers []object.Object
+	err := interp.RegisterFunc("on_event", func(fn object.Object) { handlers = append(handlers, fn) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = interp.Run(context.Background(), `let seen = []; on_event(fn(e) { seen = push(seen, e["name"]); len(seen) });`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(handlers) != 1 {
+		t.Fatalf("Expected 1 handler got %d", len(handlers))
+	}
+
+	for i, name := range []string{"start", "stop"} {
+		got, err := interp.Call(context.Background(), handlers[0], map[string]string{"name": name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		testInspect(t, got, strconv.Itoa(i+1))
+	}
+
+	seen, _ := interp.Get("seen")
+	testInspect(t, seen, "[start, stop]")
+
+	_, err = interp.Call(context.Background(), handlers[0])
+	if want, got := "runtime error: wrong number of arguments: want=1, got=0", fmt.Sprint(err); want != got {
+		t.Errorf("Expected error %q got %q", want, got)
+	}
+
+	_, err = interp.Call(context.Background(), handlers[0], make(chan int))
+	if want, got := "argument 1: unsupported Go type chan int", fmt.Sprint(err); want != got {
+		t.Errorf("Expected error %q got %q", want, got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = interp.Call(ctx, handlers[0], nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v got %v", context.Canceled, err)
+	}
+}
+
+func testInspect(t *testing.T, obj object.Object, want string) {
+	t.Helper()
+
+	if got := obj.Inspect(); want != got {
+		t.Errorf("Expected %s got %s", want, got)
+	}
+}
+
+func TestInterpreterErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		opts  []Option
+		want  string
+		cause error
+	}{
+		{"let = 5;", nil, "parse error: expected token type IDENT got =; missing prefixFn for =", nil},
+		{"1 + true", nil, "runtime error: type mismatch: INTEGER + BOOLEAN", nil},
+		{"while (true) {}", []Option{WithEvalOptions(eval.WithMaxSteps(10))}, "runtime error: evaluation stopped: step budget exceeded", eval.ErrStepBudget},
+	}
+
+	for _, tt := range tests {
+		_, err := New(tt.opts...).Run(context.Background(), tt.input)
+
+		var monkeyErr *Error
+		if !errors.As(err, &monkeyErr) {
+			t.Errorf("Expected *Error got %T (%v)", err, err)
+			continue
+		}
+		if got := err.Error(); tt.want != got {
+			t.Errorf("Expected error %q got %q", tt.want, got)
+		}
+		if got := errors.Unwrap(err); tt.cause != got {
+			t.Errorf("Expected cause %v got %v", tt.cause, got)
+		}
+	}
+}
+
+func TestInterpreterRunFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "monkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "script.mk")
+	if err := ioutil.WriteFile(path, []byte("#!/usr/bin/env -S monkey run\nlet f = fn(x) { x * 2 };\nf(21)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	interp := New()
+	got, err := interp.RunFile(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "42", got.Inspect(); want != got {
+		t.Errorf("Expected result %s got %s", want, got)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("f(true)"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = interp.RunFile(context.Background(), path)
+	if want, got := path+": runtime error: type mismatch: BOOLEAN * INTEGER", err.Error(); want != got {
+		t.Errorf("Expected error %q got %q", want, got)
+	}
+
+	if _, err := interp.RunFile(context.Background(), filepath.Join(dir, "missing.mk")); !os.IsNotExist(err) {
+		t.Errorf("Expected not exist error got %v", err)
+	}
+}
+
+func TestInterpreterCallNested(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []eval.Option
+		input string
+		want  error // The cause of the error, if any.
+		msg   string
+	}{
+		{"depth", nil, "let f = fn(n) { apply(f, n + 1) }; f(0)", nil, "runtime error: maximum call depth exceeded"},
+		{"depth without ctx", nil, "let f = fn(n) { apply_bg(f, n + 1) }; f(0)", nil, "runtime error: maximum call depth exceeded"},
+		{
+			"steps",
+			[]eval.Option{eval.WithMaxSteps(100)},
+			"apply(fn(n) { while (true) { n += 1 } }, 0)",
+			eval.ErrStepBudget,
+			"runtime error: evaluation stopped: step budget exceeded",
+		},
+		{
+			"timeout",
+			[]eval.Option{eval.WithTimeout(10 * time.Millisecond)},
+			"apply(fn(n) { while (true) { n += 1 } }, 0)",
+			context.DeadlineExceeded,
+			"runtime error: evaluation stopped: context deadline exceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		interp := New(WithEvalOptions(tt.opts...))
+		err := interp.RegisterFunc("apply", func(ctx context.Context, fn object.Object, n int) (object.Object, error) {
+			return interp.Call(ctx, fn, n)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = interp.RegisterFunc("apply_bg", func(fn object.Object, n int) (object.Object, error) {
+			return interp.Call(context.Background(), fn, n)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = interp.Run(context.Background(), tt.input)
+		if err == nil {
+			t.Errorf("%s: expected error", tt.name)
+			continue
+		}
+		if got := err.Error(); tt.msg != got {
+			t.Errorf("%s: expected error %q got %q", tt.name, tt.msg, got)
+		}
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestInterpreterCallContext(t *testing.T) {
+	interp := New()
+	err := interp.RegisterFunc("twice", func(ctx context.Context, fn object.Object, n int) (int64, error) {
+		result, err := interp.Call(ctx, fn, n)
+		if err != nil {
+			return 0, err
+		}
+		result, err = interp.Call(ctx, fn, result)
+		if err != nil {
+			return 0, err
+		}
+		return result.(*object.Integer).Value, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := interp.Run(context.Background(), "twice(fn(n) { n * 3 }, 2) + twice(fn(n) { twice(fn(m) { m + 1 }, n) }, 0)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testInspect(t, got, "22")
+
+	_, err = interp.Run(context.Background(), "twice(fn(n) { n }, 1, 2)")
+	if want, got := "runtime error: wrong number of arguments: want=2, got=3", fmt.Sprint(err); want != got {
+		t.Errorf("Expected error %q got %q", want, got)
+	}
+}
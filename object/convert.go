@@ -0,0 +1,403 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+)
+
+var (
+	objectType    = reflect.TypeOf((*Object)(nil)).Elem()
+	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// FromGo converts the Go value v to an object.
+//
+// Signed and unsigned integers become Integer, floats Float,
+// strings String and bools Boolean. Slices and arrays become Array,
+// maps and structs Hash. Maps with string, number or bool keys are
+// added to the hash in ascending order of the keys and structs in
+// the order of their fields. Struct fields are keyed by their names
+// unless renamed with a `monkey:"name"` tag; fields tagged with
+// `monkey:"-"` and unexported fields are skipped.
+// Pointers and interfaces are dereferenced and nil becomes NULL.
+// Objects are returned as is.
+func FromGo(v interface{}) (Object, error) {
+	return fromValue(reflect.ValueOf(v))
+}
+
+func fromValue(v reflect.Value) (Object, error) {
+	if !v.IsValid() {
+		return NULL, nil
+	}
+	if v.Kind() == reflect.Ptr && v.Type().Implements(objectType) {
+		if v.IsNil() {
+			return NULL, nil
+		}
+		return v.Interface().(Object), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return TRUE, nil
+		}
+		return FALSE, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Integer{Value: v.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("%d overflows int64", v.Uint())
+		}
+		return &Integer{Value: int64(v.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Float{Value: v.Float()}, nil
+	case reflect.String:
+		return &String{Value: v.String()}, nil
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return NULL, nil
+		}
+		return fromValue(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return NULL, nil
+		}
+		fallthrough
+	case reflect.Array:
+		elements := make([]Object, v.Len())
+		for i := range elements {
+			elem, err := fromValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = elem
+		}
+		return &Array{Elements: elements}, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return NULL, nil
+		}
+		hash := &Hash{}
+		for _, key := range sortedKeys(v) {
+			k, err := fromValue(key)
+			if err != nil {
+				return nil, err
+			}
+			hashable, ok := k.(Hashable)
+			if !ok {
+				return nil, fmt.Errorf("unusable as hash key: %s", k.Type())
+			}
+			val, err := fromValue(v.MapIndex(key))
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(hashable, val)
+		}
+		return hash, nil
+	case reflect.Struct:
+		hash := &Hash{}
+		for i := 0; i < v.NumField(); i++ {
+			name, ok := fieldName(v.Type().Field(i))
+			if !ok {
+				continue
+			}
+			val, err := fromValue(v.Field(i))
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(&String{Value: name}, val)
+		}
+		return hash, nil
+	}
+
+	return nil, fmt.Errorf("unsupported Go type %s", v.Type())
+}
+
+// sortedKeys returns the keys of the map v in ascending order
+// so the pairs of the hash are in the same order every time.
+// Keys of other than string, number and bool types are not sorted.
+func sortedKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		switch a.Kind() {
+		case reflect.String:
+			return a.String() < b.String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return a.Int() < b.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return a.Uint() < b.Uint()
+		case reflect.Float32, reflect.Float64:
+			return a.Float() < b.Float()
+		case reflect.Bool:
+			return !a.Bool() && b.Bool()
+		}
+		return false
+	})
+
+	return keys
+}
+
+// fieldName returns the hash key of the struct field.
+// It reports false if the field should be skipped.
+func fieldName(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" {
+		return "", false // Unexported.
+	}
+
+	switch tag := field.Tag.Get("monkey"); tag {
+	case "-":
+		return "", false
+	case "":
+		return field.Name, true
+	default:
+		return tag, true
+	}
+}
+
+// ToGo converts the obj to its natural Go representation.
+//
+// NULL becomes nil, Integer int64, Float float64, String string,
+// Boolean bool and Array []interface{}. A Hash becomes
+// map[string]interface{} if all its keys are strings
+// and map[interface{}]interface{} otherwise.
+// A nil obj, e.g. the result of a program ending in a let statement,
+// is an error.
+func ToGo(obj Object) (interface{}, error) {
+	if obj == nil {
+		return nil, fmt.Errorf("cannot convert a nil Object")
+	}
+
+	switch obj := obj.(type) {
+	case *Null:
+		return nil, nil
+	case *Boolean:
+		return obj.Value, nil
+	case *Integer:
+		return obj.Value, nil
+	case *Float:
+		return obj.Value, nil
+	case *String:
+		return obj.Value, nil
+	case *Array:
+		elements := make([]interface{}, len(obj.Elements))
+		for i := range obj.Elements {
+			elem, err := ToGo(obj.Elements[i])
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = elem
+		}
+		return elements, nil
+	case *Hash:
+		stringKeys := true
+		for _, pair := range obj.Pairs {
+			if pair.Key.Type() != STRING_OBJ {
+				stringKeys = false
+				break
+			}
+		}
+
+		if stringKeys {
+			m := make(map[string]interface{}, len(obj.Pairs))
+			for _, pair := range obj.Pairs {
+				v, err := ToGo(pair.Value)
+				if err != nil {
+					return nil, err
+				}
+				m[pair.Key.(*String).Value] = v
+			}
+			return m, nil
+		}
+
+		m := make(map[interface{}]interface{}, len(obj.Pairs))
+		for _, pair := range obj.Pairs {
+			k, err := ToGo(pair.Key)
+			if err != nil {
+				return nil, err
+			}
+			v, err := ToGo(pair.Value)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = v
+		}
+		return m, nil
+	}
+
+	return nil, fmt.Errorf("cannot convert %s to a Go value", obj.Type())
+}
+
+// Decode stores the obj in the value pointed to by v
+// converting it to the type of the value.
+//
+// Hashes are decoded into structs by matching keys to field names
+// or their `monkey:"name"` tags. Keys without matching fields are ignored.
+// Interfaces receive the result of ToGo.
+// Fields of type Object or of concrete object types receive objects as is.
+func Decode(obj Object, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("decode: non-nil pointer expected got %T", v)
+	}
+
+	return decodeValue(obj, rv.Elem())
+}
+
+// decodeValue stores the obj in the settable v.
+func decodeValue(obj Object, v reflect.Value) error {
+	if obj == nil {
+		return fmt.Errorf("cannot decode a nil Object")
+	}
+
+	t := v.Type()
+	mismatch := func() error {
+		return fmt.Errorf("cannot convert %s to %s", obj.Type(), t)
+	}
+
+	if t == objectType || (t.Kind() == reflect.Ptr && t.Implements(objectType)) {
+		if !reflect.TypeOf(obj).AssignableTo(t) {
+			return mismatch()
+		}
+		v.Set(reflect.ValueOf(obj))
+		return nil
+	}
+
+	if obj == NULL {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		b, ok := obj.(*Boolean)
+		if !ok {
+			return mismatch()
+		}
+		v.SetBool(b.Value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := obj.(*Integer)
+		if !ok {
+			return mismatch()
+		}
+		if v.OverflowInt(i.Value) {
+			return fmt.Errorf("%d overflows %s", i.Value, t)
+		}
+		v.SetInt(i.Value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, ok := obj.(*Integer)
+		if !ok {
+			return mismatch()
+		}
+		if i.Value < 0 || v.OverflowUint(uint64(i.Value)) {
+			return fmt.Errorf("%d overflows %s", i.Value, t)
+		}
+		v.SetUint(uint64(i.Value))
+	case reflect.Float32, reflect.Float64:
+		switch n := obj.(type) {
+		case *Float:
+			v.SetFloat(n.Value)
+		case *Integer:
+			v.SetFloat(float64(n.Value))
+		default:
+			return mismatch()
+		}
+	case reflect.String:
+		s, ok := obj.(*String)
+		if !ok {
+			return mismatch()
+		}
+		v.SetString(s.Value)
+	case reflect.Ptr:
+		elem := reflect.New(t.Elem())
+		if err := decodeValue(obj, elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Interface:
+		val, err := ToGo(obj)
+		if err != nil {
+			return err
+		}
+		if val == nil {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		rv := reflect.ValueOf(val)
+		if !rv.Type().AssignableTo(t) {
+			return mismatch()
+		}
+		v.Set(rv)
+	case reflect.Slice:
+		arr, ok := obj.(*Array)
+		if !ok {
+			return mismatch()
+		}
+		slice := reflect.MakeSlice(t, len(arr.Elements), len(arr.Elements))
+		for i, elem := range arr.Elements {
+			if err := decodeValue(elem, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	case reflect.Array:
+		arr, ok := obj.(*Array)
+		if !ok {
+			return mismatch()
+		}
+		if len(arr.Elements) != t.Len() {
+			return fmt.Errorf("cannot convert %s of length %d to %s", obj.Type(), len(arr.Elements), t)
+		}
+		for i, elem := range arr.Elements {
+			if err := decodeValue(elem, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		hash, ok := obj.(*Hash)
+		if !ok {
+			return mismatch()
+		}
+		m := reflect.MakeMapWithSize(t, len(hash.Pairs))
+		for _, pair := range hash.Pairs {
+			key := reflect.New(t.Key()).Elem()
+			if err := decodeValue(pair.Key, key); err != nil {
+				return err
+			}
+			val := reflect.New(t.Elem()).Elem()
+			if err := decodeValue(pair.Value, val); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, val)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		hash, ok := obj.(*Hash)
+		if !ok {
+			return mismatch()
+		}
+		for i := 0; i < t.NumField(); i++ {
+			name, ok := fieldName(t.Field(i))
+			if !ok {
+				continue
+			}
+			key := &String{Value: name}
+			pair, ok := hash.Pairs[key.HashKey()]
+			if !ok {
+				continue
+			}
+			if err := decodeValue(pair.Value, v.Field(i)); err != nil {
+				return fmt.Errorf("field %s: %v", t.Field(i).Name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported Go type %s", t)
+	}
+
+	return nil
+}
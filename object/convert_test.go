@@ -0,0 +1,227 @@
+package object
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFromGo(t *testing.T) {
+	type config struct {
+		Name    string `monkey:"name"`
+		Port    int
+		Secret  string `monkey:"-"`
+		private bool
+	}
+
+	tests := []struct {
+		input interface{}
+		want  string
+	}{
+		{nil, "null"},
+		{true, "true"},
+		{int8(-3), "-3"},
+		{uint(3), "3"},
+		{1.5, "1.5"},
+		{"monkey", "monkey"},
+		{[]int{1, 2}, "[1, 2]"},
+		{[2]string{"a", "b"}, "[a, b]"},
+		{[]int(nil), "null"},
+		{map[string]int{"a": 1}, "{a: 1}"},
+		{(*int)(nil), "null"},
+		{&Integer{Value: 1}, "1"},
+	}
+
+	for _, tt := range tests {
+		obj, err := FromGo(tt.input)
+		if err != nil {
+			t.Errorf("%#v: %v", tt.input, err)
+			continue
+		}
+		if got := obj.Inspect(); tt.want != got {
+			t.Errorf("%#v: expected %s got %s", tt.input, tt.want, got)
+		}
+	}
+
+	obj, err := FromGo(&config{Name: "m", Port: 80, Secret: "s", private: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ToGo(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"name": "m", "Port": int64(80)}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+
+	if obj, _ := FromGo(true); obj != TRUE {
+		t.Errorf("Expected TRUE singleton got %#v", obj)
+	}
+
+	if _, err := FromGo(make(chan int)); err == nil {
+		t.Errorf("Expected error for chan int")
+	}
+
+	_, err = FromGo(uint64(math.MaxInt64 + 1))
+	if want := "9223372036854775808 overflows int64"; err == nil || err.Error() != want {
+		t.Errorf("Expected error %q got %v", want, err)
+	}
+	if obj, err := FromGo(uint64(math.MaxInt64)); err != nil || obj.Inspect() != "9223372036854775807" {
+		t.Errorf("Expected 9223372036854775807 got %v, %v", obj, err)
+	}
+}
+
+func TestFromGoHashOrder(t *testing.T) {
+	tests := []struct {
+		input interface{}
+		want  string
+	}{
+		{map[string]int{"d": 4, "b": 2, "a": 1, "e": 5, "c": 3}, "{a: 1, b: 2, c: 3, d: 4, e: 5}"},
+		{map[int]bool{10: true, -1: false, 2: true}, "{-1: false, 2: true, 10: true}"},
+		{struct{ Z, A, M int }{1, 2, 3}, "{Z: 1, A: 2, M: 3}"},
+	}
+
+	for _, tt := range tests {
+		got, err := FromGo(tt.input)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.input, err)
+		}
+		if got := got.Inspect(); tt.want != got {
+			t.Errorf("%v: expected %s got %s", tt.input, tt.want, got)
+		}
+	}
+}
+
+func TestToGo(t *testing.T) {
+	tests := []struct {
+		input Object
+		want  interface{}
+	}{
+		{NULL, nil},
+		{TRUE, true},
+		{&Integer{Value: 1}, int64(1)},
+		{&Float{Value: 1.5}, 1.5},
+		{&String{Value: "a"}, "a"},
+		{&Array{Elements: []Object{&Integer{Value: 1}, NULL}}, []interface{}{int64(1), nil}},
+		{newHash(&String{Value: "a"}, &Integer{Value: 1}), map[string]interface{}{"a": int64(1)}},
+		{newHash(&Integer{Value: 1}, TRUE), map[interface{}]interface{}{int64(1): true}},
+	}
+
+	for _, tt := range tests {
+		got, err := ToGo(tt.input)
+		if err != nil {
+			t.Errorf("%s: %v", tt.input.Inspect(), err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: (-want +got)\n%s", tt.input.Inspect(), diff)
+		}
+	}
+
+	if _, err := ToGo(&Builtin{Name: "len"}); err == nil {
+		t.Errorf("Expected error for BUILTIN")
+	}
+	if _, err := ToGo(nil); err == nil {
+		t.Errorf("Expected error for a nil Object")
+	}
+	if _, err := ToGo(&Array{Elements: []Object{nil}}); err == nil {
+		t.Errorf("Expected error for an array with a nil element")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	type server struct {
+		Host    string `monkey:"host"`
+		Port    uint16 `monkey:"port"`
+		Tags    []string
+		Weight  *float64
+		Ignored string `monkey:"-"`
+		Extra   interface{}
+		Raw     Object
+	}
+
+	obj := newHash(
+		&String{Value: "host"}, &String{Value: "localhost"},
+		&String{Value: "port"}, &Integer{Value: 8080},
+		&String{Value: "Tags"}, &Array{Elements: []Object{&String{Value: "a"}}},
+		&String{Value: "Weight"}, &Integer{Value: 2},
+		&String{Value: "Ignored"}, &String{Value: "x"},
+		&String{Value: "Extra"}, &Array{Elements: []Object{TRUE}},
+		&String{Value: "Raw"}, &Integer{Value: 1},
+		&String{Value: "unknown"}, NULL,
+	)
+
+	var got server
+	if err := Decode(obj, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	weight := 2.0
+	want := server{
+		Host:   "localhost",
+		Port:   8080,
+		Tags:   []string{"a"},
+		Weight: &weight,
+		Extra:  []interface{}{true},
+		Raw:    &Integer{Value: 1},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var (
+		i   int
+		u8  uint8
+		s   string
+		arr [2]int
+		sl  []int
+		obj Object
+		str struct {
+			Port int `monkey:"port"`
+		}
+	)
+
+	tests := []struct {
+		obj  Object
+		v    interface{}
+		want string
+	}{
+		{&Integer{Value: 1}, i, "decode: non-nil pointer expected got int"},
+		{&Integer{Value: 1}, (*int)(nil), "decode: non-nil pointer expected got *int"},
+		{&String{Value: "1"}, &i, "cannot convert STRING to int"},
+		{&Integer{Value: 256}, &u8, "256 overflows uint8"},
+		{&Integer{Value: -1}, &u8, "-1 overflows uint8"},
+		{NULL, &s, "cannot convert NULL to string"},
+		{&Array{Elements: []Object{&Integer{Value: 1}}}, &arr, "cannot convert ARRAY of length 1 to [2]int"},
+		{newHash(&String{Value: "port"}, TRUE), &str, "field Port: cannot convert BOOLEAN to int"},
+		{nil, &i, "cannot decode a nil Object"},
+		{nil, &obj, "cannot decode a nil Object"},
+		{&Array{Elements: []Object{nil}}, &sl, "cannot decode a nil Object"},
+	}
+
+	for _, tt := range tests {
+		err := Decode(tt.obj, tt.v)
+		if err == nil {
+			t.Errorf("Expected error %q", tt.want)
+			continue
+		}
+		if got := err.Error(); tt.want != got {
+			t.Errorf("Expected error %q got %q", tt.want, got)
+		}
+	}
+}
+
+// newHash creates a hash from alternating keys and values.
+func newHash(kv ...Object) *Hash {
+	hash := &Hash{}
+	for i := 0; i < len(kv); i += 2 {
+		hash.Set(kv[i].(Hashable), kv[i+1])
+	}
+
+	return hash
+}
@@ -1,14 +1,33 @@
 package object
 
-import "strconv"
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/pmatseykanets/monkey/ast"
+	"github.com/pmatseykanets/monkey/token"
+)
 
 // Type .
 type Type string
 
 const (
-	INTEGER_OBJ = "INTEGER"
-	BOOLEAN_OBJ = "BOOLEAN"
-	NULL_OBJ    = "NULL"
+	INTEGER_OBJ  = "INTEGER"
+	FLOAT_OBJ    = "FLOAT"
+	STRING_OBJ   = "STRING"
+	BOOLEAN_OBJ  = "BOOLEAN"
+	NULL_OBJ     = "NULL"
+	ERROR_OBJ    = "ERROR"
+	RETURN_OBJ   = "RETURN_VALUE"
+	FUNCTION_OBJ = "FUNCTION"
+	BUILTIN_OBJ  = "BUILTIN"
+	ARRAY_OBJ    = "ARRAY"
+	HASH_OBJ     = "HASH"
+
+	BREAK_OBJ    = "BREAK"
+	CONTINUE_OBJ = "CONTINUE"
 )
 
 // Object .
@@ -17,6 +36,13 @@ type Object interface {
 	Inspect() string
 }
 
+// Singleton values shared by all Monkey programs.
+var (
+	TRUE  = &Boolean{Value: true}
+	FALSE = &Boolean{Value: false}
+	NULL  = &Null{}
+)
+
 type Integer struct {
 	Value int64
 }
@@ -28,6 +54,28 @@ func (i *Integer) Inspect() string {
 	return strconv.FormatInt(i.Value, 10)
 }
 
+type Float struct {
+	Value float64
+}
+
+func (*Float) Type() Type {
+	return FLOAT_OBJ
+}
+func (f *Float) Inspect() string {
+	return strconv.FormatFloat(f.Value, 'g', -1, 64)
+}
+
+type String struct {
+	Value string
+}
+
+func (*String) Type() Type {
+	return STRING_OBJ
+}
+func (s *String) Inspect() string {
+	return s.Value
+}
+
 type Boolean struct {
 	Value bool
 }
@@ -47,3 +95,214 @@ func (*Null) Type() Type {
 func (*Null) Inspect() string {
 	return "null"
 }
+
+// Error represents a runtime error.
+type Error struct {
+	Message string
+	Stack   []Frame // The innermost calls leading to the error, if known.
+	Cause   error   // The underlying Go error, if any.
+}
+
+func (*Error) Type() Type {
+	return ERROR_OBJ
+}
+func (e *Error) Inspect() string {
+	var buf strings.Builder
+
+	buf.WriteString("ERROR: " + e.Message)
+	for _, frame := range e.Stack {
+		buf.WriteString("\n\tat " + frame.String())
+	}
+
+	return buf.String()
+}
+
+// Frame describes a function call on the call stack.
+type Frame struct {
+	Function string         // The name of the function, empty if anonymous.
+	Pos      token.Position // The position of the call.
+}
+
+func (f Frame) String() string {
+	name := f.Function
+	if name == "" {
+		name = "<anonymous>"
+	}
+
+	return name + " (" + f.Pos.String() + ")"
+}
+
+type Array struct {
+	Elements []Object
+}
+
+func (*Array) Type() Type {
+	return ARRAY_OBJ
+}
+func (a *Array) Inspect() string {
+	elements := make([]string, len(a.Elements))
+	for i := range a.Elements {
+		elements[i] = a.Elements[i].Inspect()
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// HashKey identifies a hashable object used as a key in a Hash.
+type HashKey struct {
+	Type  Type
+	Value uint64
+}
+
+// Hashable is implemented by objects that can be used as hash keys.
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+func (b *Boolean) HashKey() HashKey {
+	var v uint64
+	if b.Value {
+		v = 1
+	}
+
+	return HashKey{Type: b.Type(), Value: v}
+}
+
+func (s *String) HashKey() HashKey {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s.Value))
+
+	return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
+
+// HashPair holds the original key object along with its value.
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// Hash maps keys to values keeping the order in which
+// the keys were first added in Keys. Pairs should be added
+// with Set to keep Keys up to date.
+type Hash struct {
+	Pairs map[HashKey]HashPair
+	Keys  []HashKey // The keys of Pairs in insertion order.
+}
+
+// Set sets the value of the key appending the key to Keys
+// if it's not in the hash yet.
+func (h *Hash) Set(key Hashable, value Object) {
+	if h.Pairs == nil {
+		h.Pairs = make(map[HashKey]HashPair)
+	}
+
+	k := key.HashKey()
+	if _, ok := h.Pairs[k]; !ok {
+		h.Keys = append(h.Keys, k)
+	}
+	h.Pairs[k] = HashPair{Key: key, Value: value}
+}
+
+// Ordered returns the pairs of the hash in insertion order.
+func (h *Hash) Ordered() []HashPair {
+	pairs := make([]HashPair, 0, len(h.Keys))
+	for _, k := range h.Keys {
+		pairs = append(pairs, h.Pairs[k])
+	}
+
+	return pairs
+}
+
+func (*Hash) Type() Type {
+	return HASH_OBJ
+}
+func (h *Hash) Inspect() string {
+	pairs := make([]string, 0, len(h.Pairs))
+	for _, pair := range h.Ordered() {
+		pairs = append(pairs, pair.Key.Inspect()+": "+pair.Value.Inspect())
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// Break signals that the innermost loop should be terminated.
+type Break struct{}
+
+func (*Break) Type() Type {
+	return BREAK_OBJ
+}
+func (*Break) Inspect() string {
+	return "break"
+}
+
+// Continue signals that the innermost loop
+// should proceed with the next iteration.
+type Continue struct{}
+
+func (*Continue) Type() Type {
+	return CONTINUE_OBJ
+}
+func (*Continue) Inspect() string {
+	return "continue"
+}
+
+// ReturnValue wraps a value returned with a return statement.
+type ReturnValue struct {
+	Value Object
+}
+
+func (*ReturnValue) Type() Type {
+	return RETURN_OBJ
+}
+func (rv *ReturnValue) Inspect() string {
+	return rv.Value.Inspect()
+}
+
+// Function is a function value closed over the environment
+// it has been defined in.
+type Function struct {
+	Name   string // The name the function has been bound to with let, if any.
+	Params []*ast.Identifier
+	Body   *ast.Block
+	Env    *Environment
+}
+
+func (*Function) Type() Type {
+	return FUNCTION_OBJ
+}
+func (f *Function) Inspect() string {
+	params := make([]string, len(f.Params))
+	for i := range f.Params {
+		params[i] = f.Params[i].String()
+	}
+
+	return "fn(" + strings.Join(params, ", ") + ") {\n" + f.Body.String() + "\n}"
+}
+
+// BuiltinFunction is the implementation of a builtin function.
+type BuiltinFunction func(args ...Object) Object
+
+// BuiltinContextFunction is the implementation of a builtin function
+// which receives the context of the evaluation calling it, e.g. to
+// call back into the evaluation with eval.ApplyContext.
+type BuiltinContextFunction func(ctx context.Context, args ...Object) Object
+
+// Builtin is a function implemented in Go.
+// CtxFn is called instead of Fn if it's set.
+type Builtin struct {
+	Name  string
+	Fn    BuiltinFunction
+	CtxFn BuiltinContextFunction
+}
+
+func (*Builtin) Type() Type {
+	return BUILTIN_OBJ
+}
+func (b *Builtin) Inspect() string {
+	return "builtin function " + b.Name
+}
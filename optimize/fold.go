@@ -0,0 +1,247 @@
+// Package optimize implements optimisation passes over the syntax tree.
+// The passes don't change the behaviour of programs, including
+// the runtime errors they report.
+package optimize
+
+import (
+	"strconv"
+
+	"github.com/pmatseykanets/monkey/ast"
+	"github.com/pmatseykanets/monkey/eval"
+	"github.com/pmatseykanets/monkey/token"
+)
+
+// Fold folds integer, boolean and string constant expressions,
+// e.g. 1 + 2 * 3 becomes 7, and removes unreachable branches
+// of if expressions with constant conditions. If expressions
+// whose values are not used are replaced with the statements
+// of the branch taken.
+// Expressions which fail at runtime, e.g. 1 / 0, are left as they are.
+// The tree is modified in place and its new root is returned.
+func Fold(node ast.Node) ast.Node {
+	return ast.Modify(node, fold)
+}
+
+func fold(node ast.Node) ast.Node {
+	switch n := node.(type) {
+	case *ast.Prefix:
+		if exp := foldPrefix(n); exp != nil {
+			return exp
+		}
+	case *ast.Infix:
+		if exp := foldInfix(n); exp != nil {
+			return exp
+		}
+	case *ast.Paren:
+		// The parentheses of a folded expression are redundant.
+		if _, ok := isTruthy(n.Value); ok {
+			return n.Value
+		}
+	case *ast.If:
+		foldIf(n)
+	case *ast.Program:
+		n.Statements = foldStatements(n.Statements)
+	case *ast.Block:
+		n.Statements = foldStatements(n.Statements)
+	}
+
+	return node
+}
+
+// foldPrefix returns the value of the prefix expression
+// or nil if it can't be folded.
+func foldPrefix(n *ast.Prefix) ast.Expression {
+	switch n.Operator {
+	case "!":
+		truthy, ok := isTruthy(n.Right)
+		if !ok {
+			return nil
+		}
+		return newBoolean(n, !truthy)
+	case "-":
+		if right, ok := n.Right.(*ast.IntegerLiteral); ok {
+			return newInteger(n, -right.Value)
+		}
+	}
+
+	return nil
+}
+
+// foldInfix returns the value of the infix expression
+// or nil if it can't be folded.
+func foldInfix(n *ast.Infix) ast.Expression {
+	switch left := n.Left.(type) {
+	case *ast.IntegerLiteral:
+		if right, ok := n.Right.(*ast.IntegerLiteral); ok {
+			return foldIntegers(n, left.Value, right.Value)
+		}
+	case *ast.StringLiteral:
+		if right, ok := n.Right.(*ast.StringLiteral); ok {
+			return foldStrings(n, left, right)
+		}
+	case *ast.Boolean:
+		if right, ok := n.Right.(*ast.Boolean); ok {
+			return foldBooleans(n, left.Value, right.Value)
+		}
+	}
+
+	return nil
+}
+
+func foldIntegers(n *ast.Infix, l, r int64) ast.Expression {
+	switch n.Operator {
+	case "+":
+		return newInteger(n, l+r)
+	case "-":
+		return newInteger(n, l-r)
+	case "*":
+		return newInteger(n, l*r)
+	case "/":
+		if r == 0 {
+			return nil // Division by zero fails at runtime.
+		}
+		return newInteger(n, l/r)
+	case "**":
+		if r < 0 {
+			return nil // Negative exponents fail at runtime.
+		}
+		return newInteger(n, eval.Pow(l, r))
+	case "<":
+		return newBoolean(n, l < r)
+	case ">":
+		return newBoolean(n, l > r)
+	case "==":
+		return newBoolean(n, l == r)
+	case "!=":
+		return newBoolean(n, l != r)
+	}
+
+	return nil
+}
+
+func foldStrings(n *ast.Infix, l, r *ast.StringLiteral) ast.Expression {
+	switch n.Operator {
+	case "+":
+		return newString(n, l.Value+r.Value, r.Quote)
+	case "==":
+		return newBoolean(n, l.Value == r.Value)
+	case "!=":
+		return newBoolean(n, l.Value != r.Value)
+	}
+
+	return nil
+}
+
+func foldBooleans(n *ast.Infix, l, r bool) ast.Expression {
+	switch n.Operator {
+	case "==":
+		return newBoolean(n, l == r)
+	case "!=":
+		return newBoolean(n, l != r)
+	}
+
+	return nil
+}
+
+// foldIf removes the branch of the if expression
+// which is never taken. The remaining branch becomes
+// the consequence of an if expression without else.
+func foldIf(n *ast.If) {
+	truthy, ok := isTruthy(n.Condition)
+	if !ok {
+		return
+	}
+
+	switch {
+	case truthy:
+		n.Alternative = nil
+	case n.Alternative != nil:
+		n.Condition = newBoolean(n.Condition, true)
+		n.Consequence, n.Alternative = n.Alternative, nil
+	default:
+		n.Consequence = &ast.Block{
+			Token:      n.Consequence.Token,
+			Statements: []ast.Statement{},
+			Rbrace:     n.Consequence.Rbrace,
+		}
+	}
+}
+
+// foldStatements replaces if expression statements with constant
+// conditions with the statements of the branch taken. Blocks don't
+// have their own scope so the statements behave the same in the
+// enclosing block. The last statement is kept as its value is
+// the value of the block.
+func foldStatements(list []ast.Statement) []ast.Statement {
+	var folded []ast.Statement
+	for i, stmt := range list {
+		if i == len(list)-1 {
+			folded = append(folded, stmt)
+			break
+		}
+
+		exp, ok := stmt.(*ast.BareExpr)
+		if !ok {
+			folded = append(folded, stmt)
+			continue
+		}
+		n, ok := ast.Unparen(exp.Value).(*ast.If)
+		if !ok {
+			folded = append(folded, stmt)
+			continue
+		}
+		truthy, ok := isTruthy(n.Condition)
+		if !ok {
+			folded = append(folded, stmt)
+			continue
+		}
+
+		// foldIf has already removed the branch not taken.
+		if truthy {
+			folded = append(folded, n.Consequence.Statements...)
+		}
+	}
+	if folded == nil {
+		folded = []ast.Statement{}
+	}
+
+	return folded
+}
+
+// isTruthy reports whether the value of the constant expression
+// is truthy. It reports false as the second value if the exp
+// is not a constant.
+func isTruthy(exp ast.Expression) (truthy, ok bool) {
+	switch exp := exp.(type) {
+	case *ast.Boolean:
+		return exp.Value, true
+	case *ast.IntegerLiteral, *ast.StringLiteral:
+		return true, true
+	}
+
+	return false, false
+}
+
+func newInteger(n ast.Node, v int64) *ast.IntegerLiteral {
+	literal := strconv.FormatInt(v, 10)
+	return &ast.IntegerLiteral{
+		Token: token.Token{Type: token.INT, Literal: literal, Pos: n.Pos()},
+		Value: v,
+	}
+}
+
+func newBoolean(n ast.Node, v bool) *ast.Boolean {
+	literal := strconv.FormatBool(v)
+	return &ast.Boolean{
+		Token: token.Token{Type: token.IdentType(literal), Literal: literal, Pos: n.Pos()},
+		Value: v,
+	}
+}
+
+func newString(n ast.Node, v string, quote token.Position) *ast.StringLiteral {
+	return &ast.StringLiteral{
+		Token: token.Token{Type: token.STRING, Literal: v, Pos: n.Pos()},
+		Value: v,
+		Quote: quote,
+	}
+}
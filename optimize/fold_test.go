@@ -0,0 +1,117 @@
+package optimize
+
+import (
+	"testing"
+
+	"github.com/pmatseykanets/monkey/ast"
+	"github.com/pmatseykanets/monkey/eval"
+	"github.com/pmatseykanets/monkey/lexer"
+	"github.com/pmatseykanets/monkey/object"
+	"github.com/pmatseykanets/monkey/parser"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.FromString(input))
+	prg := p.Parse()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("%q: parse errors %v", input, errs)
+	}
+
+	return prg
+}
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"1 + 2 * 3", "7"},
+		{"(1 + 2) * 3 - 4 / 2", "7"},
+		{"2 ** 3 ** 2", "512"},
+		{"-5 + 2", "-3"},
+		{"--5", "5"},
+		{"1 < 2", "true"},
+		{"1 > 2 == false", "true"},
+		{"1 != 1", "false"},
+		{"!true", "false"},
+		{"!!5", "true"},
+		{"!\"\"", "false"},
+		{`"foo" + "bar"`, `"foobar"`},
+		{`"a" == "a"`, "true"},
+		{`"a" != "a"`, "false"},
+		{"true == false", "false"},
+		{"true != false", "true"},
+		{"let x = 2 * 21;", "let x = 42;"},
+		{"fn(x) { x + 1 * 2 }", "fn(x) (x + 2)"},
+		{"x + 1 + 2", "((x + 1) + 2)"},
+		{"x * (1 + 2)", "(x * 3)"},
+		{"[1 + 1, {\"k\" + \"ey\": 2 * 2}][0 + 0]", "([2, {\"key\": 4}][0])"},
+
+		// Errors at runtime are not folded.
+		{"1 / 0", "(1 / 0)"},
+		{"1 / (1 - 1)", "(1 / 0)"},
+		{"2 ** -1", "(2 ** -1)"},
+		{"-true", "(-true)"},
+		{"true + false", "(true + false)"},
+		{`"a" - "b"`, `("a" - "b")`},
+		{`1 + "a"`, `(1 + "a")`},
+		{"1 + 2.5", "(1 + 2.5)"},
+
+		// Unreachable branches are removed.
+		{"if (true) { 1 } else { 2 }", "iftrue 1"},
+		{"if (1 > 2) { 1 } else { 2 }", "iftrue 2"},
+		{"if (false) { 1 }", "iffalse "},
+		{"if (x) { 1 + 1 } else { 2 }", "ifx 2else2"},
+		{"let y = if (\"s\") { 1 } else { 2 };", "let y = if\"s\" 1;"},
+
+		// Statements of the branch taken replace if statements
+		// unless they are the last in the block.
+		{"if (true) { let a = 1; a } else { 2 }; a", "let a = 1;aa"},
+		{"if (false) { 1 }; 2", "2"},
+		{"if (false) { 1 } else { 3; 4 }; 2", "342"},
+		{"fn() { if (1 == 1) { return 1; }; 2 }", "fn() return 1;2"},
+		{"fn() { 1; if (true) { 2 } }", "fn() 1iftrue 2"},
+	}
+
+	for _, tt := range tests {
+		prg := parse(t, tt.input)
+		if got := Fold(prg).String(); tt.want != got {
+			t.Errorf("%q: expected %s got %s", tt.input, tt.want, got)
+		}
+	}
+}
+
+func TestFoldPreservesSemantics(t *testing.T) {
+	tests := []string{
+		"1 + 2 * 3",
+		"9223372036854775807 + 1",
+		"-9223372036854775807 - 2",
+		"3 ** 40",
+		"7 / 2",
+		"-7 / 2",
+		"1 / 0",
+		"let x = 0; 5 / (x - x)",
+		"2 ** -1",
+		"-true",
+		`"a" * 2`,
+		"true > false",
+		"!0",
+		"!\"\"",
+		"if (0) { 1 } else { 2 }",
+		"if (false) { 1 }",
+		"let a = 1; if (true) { let a = 2; }; a",
+		"let f = fn() { if (true) { return 1; }; 2 }; f()",
+		"let f = fn() { 1; if (false) { 2 } }; f()",
+		"let s = 0; for (i in 3) { if (true) { s += i; continue; }; s = 100; } s",
+	}
+
+	for _, input := range tests {
+		want := eval.Eval(parse(t, input), object.NewEnvironment())
+		got := eval.Eval(Fold(parse(t, input)), object.NewEnvironment())
+		if want.Inspect() != got.Inspect() {
+			t.Errorf("%q: expected %s got %s", input, want.Inspect(), got.Inspect())
+		}
+	}
+}
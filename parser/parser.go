@@ -13,26 +13,63 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN      // = or +=
 	EQUALS      // ==
 	LESSGREATER // < or >
 	SUM         // +
 	PRODUCT     // *
+	POWER       // **
 	PREFIX      // -x  or !x
 	CALL        // foo(x)
-
+	INDEX       // arr[x]
 )
 
 // precedences associates token types with their precedence values.
 var precedences = map[token.TokenType]int{
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.ASTERISK: PRODUCT,
-	token.SLASH:    PRODUCT,
-	token.LPAREN:   CALL,
+	token.ASSIGN:          ASSIGN,
+	token.PLUS_ASSIGN:     ASSIGN,
+	token.MINUS_ASSIGN:    ASSIGN,
+	token.ASTERISK_ASSIGN: ASSIGN,
+	token.SLASH_ASSIGN:    ASSIGN,
+	token.EQ:              EQUALS,
+	token.NOT_EQ:          EQUALS,
+	token.LT:              LESSGREATER,
+	token.GT:              LESSGREATER,
+	token.PLUS:            SUM,
+	token.MINUS:           SUM,
+	token.ASTERISK:        PRODUCT,
+	token.SLASH:           PRODUCT,
+	token.POW:             POWER,
+	token.LPAREN:          CALL,
+	token.LBRACKET:        INDEX,
+}
+
+// rightAssoc lists right associative operators.
+// E.g.
+// 2 ** 3 ** 2 is parsed as 2 ** (3 ** 2)
+// a = b = 1 is parsed as a = (b = 1)
+var rightAssoc = map[token.TokenType]bool{
+	token.POW:             true,
+	token.ASSIGN:          true,
+	token.PLUS_ASSIGN:     true,
+	token.MINUS_ASSIGN:    true,
+	token.ASTERISK_ASSIGN: true,
+	token.SLASH_ASSIGN:    true,
+}
+
+// Precedence returns the precedence of the infix operator t
+// or LOWEST if t is not an infix operator.
+func Precedence(t token.TokenType) int {
+	if p, ok := precedences[t]; ok {
+		return p
+	}
+
+	return LOWEST
+}
+
+// IsRightAssoc reports whether the infix operator t is right associative.
+func IsRightAssoc(t token.TokenType) bool {
+	return rightAssoc[t]
 }
 
 type prefixFn func() ast.Expression
@@ -44,10 +81,13 @@ type Parser struct {
 	lex       *lexer.Lexer
 	curr      token.Token
 	next      token.Token
+	currEnd   token.Position // The position immediately after the current token.
+	nextEnd   token.Position // The position immediately after the next token.
 	errors    []error
 	prefixFns map[token.TokenType]prefixFn
 	infixFns  map[token.TokenType]infixFn
 	trace     bool
+	loops     int // The nesting depth of loops being parsed.
 }
 
 // New creates a new instance of Parser.
@@ -62,6 +102,8 @@ func New(lex *lexer.Lexer) *Parser {
 	// Register prefix parsing funstions.
 	p.prefixFns[token.IDENT] = p.parseIdentifier
 	p.prefixFns[token.INT] = p.parseIntegerLiteral
+	p.prefixFns[token.FLOAT] = p.parseFloatLiteral
+	p.prefixFns[token.STRING] = p.parseStringLiteral
 	p.prefixFns[token.BANG] = p.parsePrefixExpression
 	p.prefixFns[token.MINUS] = p.parsePrefixExpression
 	p.prefixFns[token.TRUE] = p.parseBoolean
@@ -69,17 +111,26 @@ func New(lex *lexer.Lexer) *Parser {
 	p.prefixFns[token.LPAREN] = p.parseGroupExpression
 	p.prefixFns[token.IF] = p.parseIfExpression
 	p.prefixFns[token.FUNCTION] = p.parseFunctionLiteral
+	p.prefixFns[token.LBRACKET] = p.parseArrayLiteral
+	p.prefixFns[token.LBRACE] = p.parseHashLiteral
 
 	// Register infix parsing funstions.
 	p.infixFns[token.PLUS] = p.parseInfixExpression
 	p.infixFns[token.MINUS] = p.parseInfixExpression
 	p.infixFns[token.ASTERISK] = p.parseInfixExpression
 	p.infixFns[token.SLASH] = p.parseInfixExpression
+	p.infixFns[token.POW] = p.parseInfixExpression
 	p.infixFns[token.LT] = p.parseInfixExpression
 	p.infixFns[token.GT] = p.parseInfixExpression
 	p.infixFns[token.EQ] = p.parseInfixExpression
 	p.infixFns[token.NOT_EQ] = p.parseInfixExpression
 	p.infixFns[token.LPAREN] = p.parseCallExpression
+	p.infixFns[token.LBRACKET] = p.parseIndexExpression
+	p.infixFns[token.ASSIGN] = p.parseAssignExpression
+	p.infixFns[token.PLUS_ASSIGN] = p.parseAssignExpression
+	p.infixFns[token.MINUS_ASSIGN] = p.parseAssignExpression
+	p.infixFns[token.ASTERISK_ASSIGN] = p.parseAssignExpression
+	p.infixFns[token.SLASH_ASSIGN] = p.parseAssignExpression
 
 	// Advance twice to fill in p.curr and p.next.
 	p.nextToken()
@@ -94,8 +145,9 @@ func (p *Parser) WithTrace() {
 }
 
 func (p *Parser) nextToken() {
-	p.curr = p.next
+	p.curr, p.currEnd = p.next, p.nextEnd
 	p.next = p.lex.NextToken()
+	p.nextEnd = p.lex.End()
 }
 
 func (p *Parser) expectNext(t token.TokenType) bool {
@@ -138,6 +190,14 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.WHILE:
+		return p.parseWhileStatement()
+	case token.FOR:
+		return p.parseForStatement()
+	case token.BREAK:
+		return p.parseBreakStatement()
+	case token.CONTINUE:
+		return p.parseContinueStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -162,6 +222,9 @@ func (p *Parser) parseLetStatement() *ast.Let {
 	p.nextToken()
 
 	stmt.Value = p.parseExpression(LOWEST)
+	if fn, ok := stmt.Value.(*ast.Function); ok {
+		fn.Name = stmt.Name.Value
+	}
 	if p.next.Type == token.SEMICOLON {
 		p.nextToken()
 	}
@@ -186,6 +249,111 @@ func (p *Parser) parseReturnStatement() *ast.Return {
 	return stmt
 }
 
+func (p *Parser) parseWhileStatement() *ast.While {
+	if p.trace {
+		defer untrace(trace("parseWhileStatement"))
+	}
+	stmt := &ast.While{Token: p.curr}
+
+	if !p.expectNext(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectNext(token.RPAREN) {
+		return nil
+	}
+	if !p.expectNext(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+	if p.next.Type == token.SEMICOLON {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseForStatement() *ast.For {
+	if p.trace {
+		defer untrace(trace("parseForStatement"))
+	}
+	stmt := &ast.For{Token: p.curr}
+
+	if !p.expectNext(token.LPAREN) {
+		return nil
+	}
+	if !p.expectNext(token.IDENT) {
+		return nil
+	}
+
+	stmt.Var = &ast.Identifier{Token: p.curr, Value: p.curr.Literal}
+
+	if !p.expectNext(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectNext(token.RPAREN) {
+		return nil
+	}
+	if !p.expectNext(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+	if p.next.Type == token.SEMICOLON {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseLoopBody parses a block in which break and continue are allowed.
+func (p *Parser) parseLoopBody() *ast.Block {
+	p.loops++
+	defer func() { p.loops-- }()
+
+	return p.parseBlockStatement()
+}
+
+func (p *Parser) parseBreakStatement() *ast.Break {
+	if p.trace {
+		defer untrace(trace("parseBreakStatement"))
+	}
+	stmt := &ast.Break{Token: p.curr}
+	if p.loops == 0 {
+		p.errors = append(p.errors, fmt.Errorf("break outside of loop"))
+	}
+
+	if p.next.Type == token.SEMICOLON {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseContinueStatement() *ast.Continue {
+	if p.trace {
+		defer untrace(trace("parseContinueStatement"))
+	}
+	stmt := &ast.Continue{Token: p.curr}
+	if p.loops == 0 {
+		p.errors = append(p.errors, fmt.Errorf("continue outside of loop"))
+	}
+
+	if p.next.Type == token.SEMICOLON {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
 func (p *Parser) parseExpressionStatement() *ast.BareExpr {
 	if p.trace {
 		defer untrace(trace("parseExpressionStatement"))
@@ -252,6 +420,38 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	}
 }
 
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	if p.trace {
+		defer untrace(trace("parseFloatLiteral"))
+	}
+	value, err := strconv.ParseFloat(p.curr.Literal, 64)
+	if err != nil {
+		p.errors = append(p.errors, fmt.Errorf("error parsing float literal %s", p.curr.Literal))
+		return nil
+	}
+
+	return &ast.FloatLiteral{
+		Token: p.curr,
+		Value: value,
+	}
+}
+
+func (p *Parser) parseStringLiteral() ast.Expression {
+	if p.trace {
+		defer untrace(trace("parseStringLiteral"))
+	}
+	// The closing quote is the last character of the token.
+	quote := p.currEnd
+	quote.Offset--
+	quote.Column--
+
+	return &ast.StringLiteral{
+		Token: p.curr,
+		Value: p.curr.Literal,
+		Quote: quote,
+	}
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	if p.trace {
 		defer untrace(trace("parsePrefixExpression"))
@@ -284,6 +484,19 @@ func (p *Parser) peekPrecedence() int {
 	return LOWEST
 }
 
+// rightPrecedence returns the precedence the right operand
+// of the current infix operator should be parsed with.
+func (p *Parser) rightPrecedence() int {
+	precedence := p.currPrecedence()
+	if rightAssoc[p.curr.Type] {
+		// Parse the right operand with a slightly lower precedence
+		// so that the same operator binds to the right.
+		precedence--
+	}
+
+	return precedence
+}
+
 func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	if p.trace {
 		defer untrace(trace("parseInfixExpression"))
@@ -294,7 +507,7 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 		Left:     left,
 	}
 
-	precedence := p.currPrecedence()
+	precedence := p.rightPrecedence()
 	p.nextToken()
 	exp.Right = p.parseExpression(precedence)
 
@@ -315,12 +528,14 @@ func (p *Parser) parseGroupExpression() ast.Expression {
 	if p.trace {
 		defer untrace(trace("parseGroupExpression"))
 	}
+	exp := &ast.Paren{Token: p.curr}
 	p.nextToken()
 
-	exp := p.parseExpression(LOWEST)
+	exp.Value = p.parseExpression(LOWEST)
 	if !p.expectNext(token.RPAREN) {
 		return nil
 	}
+	exp.Rparen = p.curr.Pos
 
 	return exp
 }
@@ -378,6 +593,7 @@ func (p *Parser) parseBlockStatement() *ast.Block {
 		}
 		p.nextToken()
 	}
+	block.Rbrace = p.curr.Pos
 
 	return block
 }
@@ -388,6 +604,12 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	}
 	fn := &ast.Function{Token: p.curr}
 
+	// A function body starts a new context
+	// where break and continue are not allowed.
+	loops := p.loops
+	p.loops = 0
+	defer func() { p.loops = loops }()
+
 	if !p.expectNext(token.LPAREN) {
 		return nil
 	}
@@ -431,36 +653,137 @@ func (p *Parser) parseCallExpression(fn ast.Expression) ast.Expression {
 	if p.trace {
 		defer untrace(trace("parseCallExpression"))
 	}
-	return &ast.Call{
-		Token:    p.curr,
-		Function: fn,
-		Args:     p.parseCallArgs(),
-	}
+	call := &ast.Call{Token: p.curr, Function: fn}
+	call.Args = p.parseCallArgs()
+	call.Rparen = p.curr.Pos
+
+	return call
 }
 
 func (p *Parser) parseCallArgs() []ast.Expression {
 	if p.trace {
 		defer untrace(trace("parseCallArgs"))
 	}
-	args := make([]ast.Expression, 0)
+	return p.parseExpressionList(token.RPAREN)
+}
 
-	if p.next.Type == token.RPAREN {
+// parseExpressionList parses a comma separated list
+// of expressions terminated by the end token.
+func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	if p.trace {
+		defer untrace(trace("parseExpressionList"))
+	}
+	list := make([]ast.Expression, 0)
+
+	if p.next.Type == end {
 		p.nextToken()
-		return args
+		return list
 	}
 
 	p.nextToken()
-	args = append(args, p.parseExpression(LOWEST))
+	list = append(list, p.parseExpression(LOWEST))
 
 	for p.next.Type == token.COMMA {
 		p.nextToken()
 		p.nextToken()
-		args = append(args, p.parseExpression(LOWEST))
+		list = append(list, p.parseExpression(LOWEST))
 	}
 
-	if !p.expectNext(token.RPAREN) {
+	if !p.expectNext(end) {
 		return nil
 	}
 
-	return args
+	return list
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	if p.trace {
+		defer untrace(trace("parseArrayLiteral"))
+	}
+	arr := &ast.ArrayLiteral{Token: p.curr}
+	arr.Elements = p.parseExpressionList(token.RBRACKET)
+	arr.Rbrack = p.curr.Pos
+
+	return arr
+}
+
+func (p *Parser) parseHashLiteral() ast.Expression {
+	if p.trace {
+		defer untrace(trace("parseHashLiteral"))
+	}
+	hash := &ast.HashLiteral{
+		Token:  p.curr,
+		Keys:   make([]ast.Expression, 0),
+		Values: make([]ast.Expression, 0),
+	}
+
+	for p.next.Type != token.RBRACE {
+		p.nextToken()
+		key := p.parseExpression(LOWEST)
+
+		if !p.expectNext(token.COLON) {
+			return nil
+		}
+
+		p.nextToken()
+		value := p.parseExpression(LOWEST)
+
+		hash.Keys = append(hash.Keys, key)
+		hash.Values = append(hash.Values, value)
+
+		if p.next.Type != token.RBRACE && !p.expectNext(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectNext(token.RBRACE) {
+		return nil
+	}
+	hash.Rbrace = p.curr.Pos
+
+	return hash
+}
+
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	if p.trace {
+		defer untrace(trace("parseIndexExpression"))
+	}
+	exp := &ast.Index{
+		Token: p.curr,
+		Left:  left,
+	}
+
+	p.nextToken()
+	exp.Index = p.parseExpression(LOWEST)
+
+	if !p.expectNext(token.RBRACKET) {
+		return nil
+	}
+	exp.Rbrack = p.curr.Pos
+
+	return exp
+}
+
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	if p.trace {
+		defer untrace(trace("parseAssignExpression"))
+	}
+	switch ast.Unparen(target).(type) {
+	case *ast.Identifier, *ast.Index:
+	default:
+		p.errors = append(p.errors, fmt.Errorf("invalid assignment target %s", target))
+		return nil
+	}
+
+	exp := &ast.Assign{
+		Token:    p.curr,
+		Target:   target,
+		Operator: p.curr.Literal,
+	}
+
+	precedence := p.rightPrecedence()
+	p.nextToken()
+	exp.Value = p.parseExpression(precedence)
+
+	return exp
 }
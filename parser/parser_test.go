@@ -1,9 +1,12 @@
 package parser
 
 import (
+	"fmt"
 	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/pmatseykanets/monkey/ast"
 	"github.com/pmatseykanets/monkey/lexer"
 )
@@ -26,6 +29,7 @@ func TestParseLetStatement(t *testing.T) {
 
 		prg := p.Parse()
 		checkParseErrors(t, p)
+		checkJSONRoundTrip(t, prg)
 		if prg == nil {
 			t.Fatal("Program is nil")
 		}
@@ -80,6 +84,24 @@ func checkParseErrors(t *testing.T, p *Parser) {
 	t.FailNow()
 }
 
+// checkJSONRoundTrip checks that the program is decoded from
+// its JSON encoding unchanged.
+func checkJSONRoundTrip(t *testing.T, prg *ast.Program) {
+	t.Helper()
+
+	data, err := ast.EncodeJSON(prg)
+	if err != nil {
+		t.Fatalf("Unexpected error encoding %s: %v", prg, err)
+	}
+	got, err := ast.DecodeJSON(data)
+	if err != nil {
+		t.Fatalf("Unexpected error decoding %s: %v", data, err)
+	}
+	if diff := cmp.Diff(prg, got); diff != "" {
+		t.Errorf("Unexpected JSON round trip of %s (-want +got):\n%s", prg, diff)
+	}
+}
+
 func TestParseReturnStatement(t *testing.T) {
 	tests := []struct {
 		input string
@@ -95,6 +117,7 @@ func TestParseReturnStatement(t *testing.T) {
 
 		prg := p.Parse()
 		checkParseErrors(t, p)
+		checkJSONRoundTrip(t, prg)
 		if prg == nil {
 			t.Fatal("Program is nil")
 		}
@@ -123,6 +146,7 @@ func TestParseIdentifierExpression(t *testing.T) {
 
 	prg := p.Parse()
 	checkParseErrors(t, p)
+	checkJSONRoundTrip(t, prg)
 	if prg == nil {
 		t.Fatal("Program is nil")
 	}
@@ -200,6 +224,7 @@ func TestParseIntegerLiteralExpression(t *testing.T) {
 
 	prg := p.Parse()
 	checkParseErrors(t, p)
+	checkJSONRoundTrip(t, prg)
 	if prg == nil {
 		t.Fatal("Program is nil")
 	}
@@ -217,6 +242,38 @@ func TestParseIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestParseFloatLiteralExpression(t *testing.T) {
+	input := "3.14;"
+
+	p := New(lexer.FromString(input))
+
+	prg := p.Parse()
+	checkParseErrors(t, p)
+	checkJSONRoundTrip(t, prg)
+	if prg == nil {
+		t.Fatal("Program is nil")
+	}
+	if want, got := 1, len(prg.Statements); want != got {
+		t.Fatalf("Expected number of statements %d got %d", want, got)
+	}
+
+	stmt, ok := prg.Statements[0].(*ast.BareExpr)
+	if !ok {
+		t.Fatalf("Expected *ast.BareExpr got %T", prg.Statements[0])
+	}
+
+	float, ok := stmt.Value.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("Expected *ast.FloatLiteral got %T", stmt.Value)
+	}
+	if want, got := 3.14, float.Value; want != got {
+		t.Errorf("Expected Value %v got %v", want, got)
+	}
+	if want, got := "3.14", float.TokenLiteral(); want != got {
+		t.Errorf("Expected TokenLiteral %s got %s", want, got)
+	}
+}
+
 func TestParsePrefixExpressions(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -233,6 +290,7 @@ func TestParsePrefixExpressions(t *testing.T) {
 		p := New(lexer.FromString(tt.input))
 		prg := p.Parse()
 		checkParseErrors(t, p)
+		checkJSONRoundTrip(t, prg)
 
 		if prg == nil {
 			t.Fatal("Program is nil")
@@ -290,6 +348,7 @@ func TestParseInfixExpressions(t *testing.T) {
 		{"5 - 5", 5, "-", 5},
 		{"5 * 5", 5, "*", 5},
 		{"5 / 5", 5, "/", 5},
+		{"5 ** 5", 5, "**", 5},
 		{"5 > 5", 5, ">", 5},
 		{"5 < 5", 5, "<", 5},
 		{"5 == 5", 5, "==", 5},
@@ -303,6 +362,7 @@ func TestParseInfixExpressions(t *testing.T) {
 		p := New(lexer.FromString(tt.input))
 		prg := p.Parse()
 		checkParseErrors(t, p)
+		checkJSONRoundTrip(t, prg)
 
 		if prg == nil {
 			t.Fatal("Program is nil")
@@ -351,12 +411,25 @@ func TestParseOperatorPrecedence(t *testing.T) {
 		{"a + add(b * c) + d", "((a + add((b * c))) + d)"},
 		{"add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8))", "add(a, b, 1, (2 * 3), (4 + 5), add(6, (7 * 8)))"},
 		{"add(a + b + c * d / f + g)", "add((((a + b) + ((c * d) / f)) + g))"},
+		{"2 ** 3 ** 2", "(2 ** (3 ** 2))"},
+		{"a * b ** c", "(a * (b ** c))"},
+		{"a ** b * c", "((a ** b) * c)"},
+		{"-a ** b", "((-a) ** b)"},
+		{"a ** -b", "(a ** (-b))"},
+		{"a * [1, 2, 3, 4][b * c] * d", "((a * ([1, 2, 3, 4][(b * c)])) * d)"},
+		{"add(a * b[2], b[1], 2 * [1, 2][1])", "add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))"},
+		{"x = 1 + 2", "(x = (1 + 2))"},
+		{"x = y = z", "(x = (y = z))"},
+		{"x += y * 2", "(x += (y * 2))"},
+		{"a[i] = a[i] + 1", "((a[i]) = ((a[i]) + 1))"},
+		{"x = y == z", "(x = (y == z))"},
 	}
 
 	for _, tt := range tests {
 		p := New(lexer.FromString(tt.input))
 		prg := p.Parse()
 		checkParseErrors(t, p)
+		checkJSONRoundTrip(t, prg)
 
 		if prg == nil {
 			t.Fatal("Program is nil")
@@ -401,6 +474,7 @@ func TestParseBooleanLiteralExpression(t *testing.T) {
 
 		prg := p.Parse()
 		checkParseErrors(t, p)
+		checkJSONRoundTrip(t, prg)
 		if prg == nil {
 			t.Fatal("Program is nil")
 		}
@@ -426,6 +500,7 @@ func TestParseIfExpression(t *testing.T) {
 
 	prg := p.Parse()
 	checkParseErrors(t, p)
+	checkJSONRoundTrip(t, prg)
 	if prg == nil {
 		t.Fatal("Program is nil")
 	}
@@ -477,6 +552,7 @@ func TestParseIfElseExpression(t *testing.T) {
 
 	prg := p.Parse()
 	checkParseErrors(t, p)
+	checkJSONRoundTrip(t, prg)
 	if prg == nil {
 		t.Fatal("Program is nil")
 	}
@@ -528,6 +604,7 @@ func TestParseFunctionLiteral(t *testing.T) {
 
 	prg := p.Parse()
 	checkParseErrors(t, p)
+	checkJSONRoundTrip(t, prg)
 	if prg == nil {
 		t.Fatal("Program is nil")
 	}
@@ -579,6 +656,7 @@ func TestParseFunctionArgs(t *testing.T) {
 
 		prg := p.Parse()
 		checkParseErrors(t, p)
+		checkJSONRoundTrip(t, prg)
 		if prg == nil {
 			t.Fatal("Program is nil")
 		}
@@ -602,6 +680,7 @@ func TestParseCallExpression(t *testing.T) {
 	p := New(lexer.FromString(input))
 	prg := p.Parse()
 	checkParseErrors(t, p)
+	checkJSONRoundTrip(t, prg)
 	if prg == nil {
 		t.Fatal("Program is nil")
 	}
@@ -646,6 +725,7 @@ func TestParseCallExpressionArgs(t *testing.T) {
 		p := New(lexer.FromString(tt.input))
 		prg := p.Parse()
 		checkParseErrors(t, p)
+		checkJSONRoundTrip(t, prg)
 		if prg == nil {
 			t.Fatal("Program is nil")
 		}
@@ -671,3 +751,362 @@ func TestParseCallExpressionArgs(t *testing.T) {
 		}
 	}
 }
+
+func TestParseStringLiteralExpression(t *testing.T) {
+	input := `"hello world";`
+
+	p := New(lexer.FromString(input))
+	prg := p.Parse()
+	checkParseErrors(t, p)
+	checkJSONRoundTrip(t, prg)
+
+	stmt := prg.Statements[0].(*ast.BareExpr)
+	str, ok := stmt.Value.(*ast.StringLiteral)
+	if !ok {
+		t.Fatalf("Expected *ast.StringLiteral got %T", stmt.Value)
+	}
+	if want, got := "hello world", str.Value; want != got {
+		t.Errorf("Expected Value %q got %q", want, got)
+	}
+}
+
+func TestParseArrayLiteral(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	p := New(lexer.FromString(input))
+	prg := p.Parse()
+	checkParseErrors(t, p)
+	checkJSONRoundTrip(t, prg)
+
+	stmt := prg.Statements[0].(*ast.BareExpr)
+	arr, ok := stmt.Value.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("Expected *ast.ArrayLiteral got %T", stmt.Value)
+	}
+	if want, got := 3, len(arr.Elements); want != got {
+		t.Fatalf("Expected elements %d got %d", want, got)
+	}
+
+	testLiteralExpression(t, arr.Elements[0], 1)
+	testInfixExpression(t, arr.Elements[1], 2, "*", 2)
+	testInfixExpression(t, arr.Elements[2], 3, "+", 3)
+}
+
+func TestParseIndexExpression(t *testing.T) {
+	input := "myArray[1 + 1]"
+
+	p := New(lexer.FromString(input))
+	prg := p.Parse()
+	checkParseErrors(t, p)
+	checkJSONRoundTrip(t, prg)
+
+	stmt := prg.Statements[0].(*ast.BareExpr)
+	idx, ok := stmt.Value.(*ast.Index)
+	if !ok {
+		t.Fatalf("Expected *ast.Index got %T", stmt.Value)
+	}
+
+	if !testIdentifier(t, idx.Left, "myArray") {
+		return
+	}
+	testInfixExpression(t, idx.Index, 1, "+", 1)
+}
+
+func TestParseHashLiteral(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"{}", "{}"},
+		{`{"one": 1, "two": 2, "three": 3}`, `{"one": 1, "two": 2, "three": 3}`},
+		{`{"one": 0 + 1, true: 10 - 8}`, `{"one": (0 + 1), true: (10 - 8)}`},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.FromString(tt.input))
+		prg := p.Parse()
+		checkParseErrors(t, p)
+		checkJSONRoundTrip(t, prg)
+
+		stmt := prg.Statements[0].(*ast.BareExpr)
+		hash, ok := stmt.Value.(*ast.HashLiteral)
+		if !ok {
+			t.Fatalf("Expected *ast.HashLiteral got %T", stmt.Value)
+		}
+		if got := hash.String(); tt.want != got {
+			t.Errorf("Expected %s got %s", tt.want, got)
+		}
+	}
+}
+
+func TestParseAssignExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		target   string
+		operator string
+		value    interface{}
+	}{
+		{"x = 5;", "x", "=", 5},
+		{"x += 5;", "x", "+=", 5},
+		{"x -= y;", "x", "-=", "y"},
+		{"x *= 2;", "x", "*=", 2},
+		{"x /= 2;", "x", "/=", 2},
+		{"a[0] = true;", "(a[0])", "=", true},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.FromString(tt.input))
+		prg := p.Parse()
+		checkParseErrors(t, p)
+		checkJSONRoundTrip(t, prg)
+
+		stmt := prg.Statements[0].(*ast.BareExpr)
+		exp, ok := stmt.Value.(*ast.Assign)
+		if !ok {
+			t.Fatalf("Expected *ast.Assign got %T", stmt.Value)
+		}
+		if want, got := tt.target, exp.Target.String(); want != got {
+			t.Errorf("Expected Target %s got %s", want, got)
+		}
+		if want, got := tt.operator, exp.Operator; want != got {
+			t.Errorf("Expected Operator %s got %s", want, got)
+		}
+		testLiteralExpression(t, exp.Value, tt.value)
+	}
+}
+
+func TestParseInvalidAssignTarget(t *testing.T) {
+	tests := []string{
+		"1 = 2",
+		"a + b = 2",
+		"f() = 2",
+	}
+
+	for _, input := range tests {
+		p := New(lexer.FromString(input))
+		p.Parse()
+		if len(p.Errors()) == 0 {
+			t.Errorf("Expected parse errors for %q", input)
+		}
+	}
+}
+
+func TestParseWhileStatement(t *testing.T) {
+	input := "while (x < y) { x += 1; break; }"
+
+	p := New(lexer.FromString(input))
+	prg := p.Parse()
+	checkParseErrors(t, p)
+	checkJSONRoundTrip(t, prg)
+	if want, got := 1, len(prg.Statements); want != got {
+		t.Fatalf("Expected number of statements %d got %d", want, got)
+	}
+
+	stmt, ok := prg.Statements[0].(*ast.While)
+	if !ok {
+		t.Fatalf("Expected *ast.While got %T", prg.Statements[0])
+	}
+	if !testInfixExpression(t, stmt.Condition, "x", "<", "y") {
+		return
+	}
+	if want, got := 2, len(stmt.Body.Statements); want != got {
+		t.Fatalf("Expected body statements %d got %d", want, got)
+	}
+	if _, ok := stmt.Body.Statements[1].(*ast.Break); !ok {
+		t.Errorf("Expected *ast.Break got %T", stmt.Body.Statements[1])
+	}
+}
+
+func TestParseForStatement(t *testing.T) {
+	input := "for (x in [1, 2]) { if (x == 1) { continue; } y += x; }"
+
+	p := New(lexer.FromString(input))
+	prg := p.Parse()
+	checkParseErrors(t, p)
+	checkJSONRoundTrip(t, prg)
+	if want, got := 1, len(prg.Statements); want != got {
+		t.Fatalf("Expected number of statements %d got %d", want, got)
+	}
+
+	stmt, ok := prg.Statements[0].(*ast.For)
+	if !ok {
+		t.Fatalf("Expected *ast.For got %T", prg.Statements[0])
+	}
+	if !testIdentifier(t, stmt.Var, "x") {
+		return
+	}
+	if want, got := "[1, 2]", stmt.Iterable.String(); want != got {
+		t.Errorf("Expected Iterable %s got %s", want, got)
+	}
+	if want, got := 2, len(stmt.Body.Statements); want != got {
+		t.Fatalf("Expected body statements %d got %d", want, got)
+	}
+}
+
+func TestParseLoopTrailingSemicolon(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string // The types of the statements.
+	}{
+		{"let x = 2; while (x > 0) { x -= 1 }; x", []string{"*ast.Let", "*ast.While", "*ast.BareExpr"}},
+		{"let s = 0; for (i in 3) { s += i }; s", []string{"*ast.Let", "*ast.For", "*ast.BareExpr"}},
+		{"while (true) { break; };", []string{"*ast.While"}},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.FromString(tt.input))
+		prg := p.Parse()
+		checkParseErrors(t, p)
+		checkJSONRoundTrip(t, prg)
+
+		var got []string
+		for _, stmt := range prg.Statements {
+			got = append(got, fmt.Sprintf("%T", stmt))
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%q: unexpected statements (-want +got):\n%s", tt.input, diff)
+		}
+	}
+}
+
+func TestParseLoopControlOutsideOfLoop(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"break;", "break outside of loop"},
+		{"continue;", "continue outside of loop"},
+		{"if (true) { break; }", "break outside of loop"},
+		{"while (true) { fn() { continue; } }", "continue outside of loop"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.FromString(tt.input))
+		p.Parse()
+		errs := p.Errors()
+		if want, got := 1, len(errs); want != got {
+			t.Fatalf("Expected errors %d got %d (%v)", want, got, errs)
+		}
+		if got := errs[0].Error(); tt.want != got {
+			t.Errorf("Expected error %q got %q", tt.want, got)
+		}
+	}
+}
+
+func TestParseFunctionName(t *testing.T) {
+	input := "let myFunction = fn() { };"
+
+	p := New(lexer.FromString(input))
+	prg := p.Parse()
+	checkParseErrors(t, p)
+	checkJSONRoundTrip(t, prg)
+
+	stmt := prg.Statements[0].(*ast.Let)
+	fn, ok := stmt.Value.(*ast.Function)
+	if !ok {
+		t.Fatalf("Expected *ast.Function got %T", stmt.Value)
+	}
+	if want, got := "myFunction", fn.Name; want != got {
+		t.Errorf("Expected Name %s got %s", want, got)
+	}
+}
+
+func TestParsePositions(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string // The source text of the nodes in the order they are visited.
+	}{
+		{
+			"let x = -a + 2.5;",
+			[]string{"Program: let x = -a + 2.5", "Let: let x = -a + 2.5", "Identifier: x", "Infix: -a + 2.5", "Prefix: -a", "Identifier: a", "FloatLiteral: 2.5"},
+		},
+		{
+			"return (1 + 2) * 3;",
+			[]string{
+				"Program: return (1 + 2) * 3", "Return: return (1 + 2) * 3", "Infix: (1 + 2) * 3",
+				"Paren: (1 + 2)", "Infix: 1 + 2", "IntegerLiteral: 1", "IntegerLiteral: 2", "IntegerLiteral: 3",
+			},
+		},
+		{
+			"(a)[0] = (f)(-(b))",
+			[]string{
+				"Program: (a)[0] = (f)(-(b))", "BareExpr: (a)[0] = (f)(-(b))", "Assign: (a)[0] = (f)(-(b))",
+				"Index: (a)[0]", "Paren: (a)", "Identifier: a", "IntegerLiteral: 0",
+				"Call: (f)(-(b))", "Paren: (f)", "Identifier: f", "Prefix: -(b)", "Paren: (b)", "Identifier: b",
+			},
+		},
+		{
+			"if (ok) { \"a\\tb\" } else {}",
+			[]string{
+				"Program: if (ok) { \"a\\tb\" } else {}", "BareExpr: if (ok) { \"a\\tb\" } else {}", "If: if (ok) { \"a\\tb\" } else {}",
+				"Identifier: ok", "Block: { \"a\\tb\" }", "BareExpr: \"a\\tb\"", "StringLiteral: \"a\\tb\"", "Block: {}",
+			},
+		},
+		{
+			"f(fn(x) { x }, [true], {1: 2})[0] = 1",
+			[]string{
+				"Program: f(fn(x) { x }, [true], {1: 2})[0] = 1", "BareExpr: f(fn(x) { x }, [true], {1: 2})[0] = 1",
+				"Assign: f(fn(x) { x }, [true], {1: 2})[0] = 1", "Index: f(fn(x) { x }, [true], {1: 2})[0]",
+				"Call: f(fn(x) { x }, [true], {1: 2})", "Identifier: f",
+				"Function: fn(x) { x }", "Identifier: x", "Block: { x }", "BareExpr: x", "Identifier: x",
+				"ArrayLiteral: [true]", "Boolean: true", "HashLiteral: {1: 2}", "IntegerLiteral: 1", "IntegerLiteral: 2",
+				"IntegerLiteral: 0", "IntegerLiteral: 1",
+			},
+		},
+		{
+			"while (x) { break; }\nfor (ё in xs) { continue }",
+			[]string{
+				"Program: while (x) { break; }\nfor (ё in xs) { continue }",
+				"While: while (x) { break; }", "Identifier: x", "Block: { break; }", "Break: break",
+				"For: for (ё in xs) { continue }", "Identifier: ё", "Identifier: xs", "Block: { continue }", "Continue: continue",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.FromString(tt.input))
+		prg := p.Parse()
+		checkParseErrors(t, p)
+		checkJSONRoundTrip(t, prg)
+
+		var got []string
+		ast.Inspect(prg, func(n ast.Node) bool {
+			if n != nil {
+				name := strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast.")
+				got = append(got, name+": "+tt.input[n.Pos().Offset:n.End().Offset])
+			}
+			return true
+		})
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%q: unexpected node spans (-want +got):\n%s", tt.input, diff)
+		}
+	}
+}
+
+func TestParsePositionsLineColumn(t *testing.T) {
+	input := "let s = \"a\nb\";\nlet ё = [\n  s\n];"
+
+	p := New(lexer.FromString(input))
+	prg := p.Parse()
+	checkParseErrors(t, p)
+	checkJSONRoundTrip(t, prg)
+
+	tests := []struct {
+		node       ast.Node
+		start, end string
+	}{
+		{prg, "1:1", "5:2"},
+		{prg.Statements[0].(*ast.Let).Value, "1:9", "2:3"},
+		{prg.Statements[1].(*ast.Let).Name, "3:5", "3:6"},
+		{prg.Statements[1].(*ast.Let).Value, "3:9", "5:2"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.node.Pos().String(); tt.start != got {
+			t.Errorf("%s: expected Pos %s got %s", tt.node, tt.start, got)
+		}
+		if got := tt.node.End().String(); tt.end != got {
+			t.Errorf("%s: expected End %s got %s", tt.node, tt.end, got)
+		}
+	}
+}
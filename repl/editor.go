@@ -0,0 +1,439 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxHistory is the maximum number of history entries kept.
+const maxHistory = 1000
+
+// errInterrupt is returned by readLine when the user presses Ctrl-C.
+var errInterrupt = errors.New("interrupt")
+
+// Control keys.
+const (
+	ctrlA     = 1
+	ctrlB     = 2
+	ctrlC     = 3
+	ctrlD     = 4
+	ctrlE     = 5
+	ctrlF     = 6
+	ctrlG     = 7
+	ctrlH     = 8
+	tab       = 9
+	ctrlK     = 11
+	ctrlL     = 12
+	enter     = 13
+	ctrlN     = 14
+	ctrlP     = 16
+	ctrlR     = 18
+	ctrlU     = 21
+	ctrlW     = 23
+	esc       = 27
+	backspace = 127
+)
+
+// Keys sent as escape sequences.
+const (
+	keyUp rune = -(iota + 1)
+	keyDown
+	keyRight
+	keyLeft
+	keyHome
+	keyEnd
+	keyDelete
+	keyUnknown
+)
+
+// editor reads lines from a terminal in raw mode
+// providing line editing, history and completion.
+type editor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	history  []string
+	histFile string // The file new history entries are appended to, if any.
+	// complete returns the candidates for completing the word
+	// before the cursor.
+	complete func() []string
+	// highlight, if set, colours the line being edited.
+	highlight func(string) string
+
+	// The state of the line being edited.
+	prompt string
+	buf    []rune
+	pos    int // The position of the cursor in buf.
+}
+
+// newEditor creates an editor reading keys from r and writing to w.
+func newEditor(r io.Reader, w io.Writer) *editor {
+	return &editor{in: bufio.NewReader(r), out: w}
+}
+
+// loadHistory reads the history from the file at path
+// and appends new entries to it from now on.
+// A missing file is not an error.
+func (e *editor) loadHistory(path string) error {
+	e.histFile = path
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	e.history = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+
+	return nil
+}
+
+// addHistory adds the line to the history unless it's empty
+// or repeats the last entry.
+func (e *editor) addHistory(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if n := len(e.history); n > 0 && e.history[n-1] == line {
+		return
+	}
+
+	e.history = append(e.history, line)
+	if len(e.history) > maxHistory {
+		e.history = e.history[1:]
+	}
+
+	if e.histFile == "" {
+		return
+	}
+	f, err := os.OpenFile(e.histFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return // The history is a convenience, don't bother the user.
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+// readLine reads a line showing the prompt.
+// It returns io.EOF on Ctrl-D on an empty line
+// and errInterrupt on Ctrl-C.
+func (e *editor) readLine(prompt string) (string, error) {
+	e.prompt, e.buf, e.pos = prompt, nil, 0
+
+	hist := len(e.history) // The history entry being shown.
+	var saved []rune       // The line being edited before browsing history.
+
+	e.refresh()
+	for {
+		key, err := e.readKey()
+		if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case enter, '\n':
+			fmt.Fprint(e.out, "\n")
+			line := string(e.buf)
+			e.addHistory(line)
+			return line, nil
+		case ctrlC:
+			fmt.Fprint(e.out, "^C\n")
+			return "", errInterrupt
+		case ctrlD:
+			if len(e.buf) == 0 {
+				fmt.Fprint(e.out, "\n")
+				return "", io.EOF
+			}
+			e.deleteAt(e.pos)
+		case keyDelete:
+			e.deleteAt(e.pos)
+		case backspace, ctrlH:
+			if e.pos > 0 {
+				e.pos--
+				e.deleteAt(e.pos)
+			}
+		case ctrlA, keyHome:
+			e.pos = 0
+		case ctrlE, keyEnd:
+			e.pos = len(e.buf)
+		case ctrlB, keyLeft:
+			if e.pos > 0 {
+				e.pos--
+			}
+		case ctrlF, keyRight:
+			if e.pos < len(e.buf) {
+				e.pos++
+			}
+		case ctrlK:
+			e.buf = e.buf[:e.pos]
+		case ctrlU:
+			e.buf = append([]rune{}, e.buf[e.pos:]...)
+			e.pos = 0
+		case ctrlW:
+			start := e.pos
+			for start > 0 && unicode.IsSpace(e.buf[start-1]) {
+				start--
+			}
+			for start > 0 && !unicode.IsSpace(e.buf[start-1]) {
+				start--
+			}
+			e.buf = append(e.buf[:start], e.buf[e.pos:]...)
+			e.pos = start
+		case ctrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case ctrlP, keyUp:
+			if hist > 0 {
+				if hist == len(e.history) {
+					saved = e.buf
+				}
+				hist--
+				e.setLine([]rune(e.history[hist]))
+			}
+		case ctrlN, keyDown:
+			if hist < len(e.history) {
+				hist++
+				if hist == len(e.history) {
+					e.setLine(saved)
+				} else {
+					e.setLine([]rune(e.history[hist]))
+				}
+			}
+		case ctrlR:
+			submit, err := e.search()
+			if err != nil {
+				return "", err
+			}
+			if submit {
+				fmt.Fprint(e.out, "\n")
+				line := string(e.buf)
+				e.addHistory(line)
+				return line, nil
+			}
+		case tab:
+			e.completeWord()
+		default:
+			if key >= 0 && unicode.IsPrint(key) {
+				e.insert(key)
+			}
+		}
+
+		e.refresh()
+	}
+}
+
+// readKey reads a key decoding escape sequences
+// of the cursor and editing keys.
+func (e *editor) readKey() (rune, error) {
+	r, _, err := e.in.ReadRune()
+	if err != nil || r != esc {
+		return r, err
+	}
+
+	r, _, err = e.in.ReadRune()
+	if err != nil {
+		return esc, nil
+	}
+	if r != '[' && r != 'O' {
+		return keyUnknown, nil
+	}
+
+	// Parameters are followed by the final byte in the range @ to ~.
+	var params []rune
+	for {
+		r, _, err = e.in.ReadRune()
+		if err != nil {
+			return keyUnknown, nil
+		}
+		if r >= '@' && r <= '~' {
+			break
+		}
+		params = append(params, r)
+	}
+
+	switch r {
+	case 'A':
+		return keyUp, nil
+	case 'B':
+		return keyDown, nil
+	case 'C':
+		return keyRight, nil
+	case 'D':
+		return keyLeft, nil
+	case 'H':
+		return keyHome, nil
+	case 'F':
+		return keyEnd, nil
+	case '~':
+		switch string(params) {
+		case "1", "7":
+			return keyHome, nil
+		case "4", "8":
+			return keyEnd, nil
+		case "3":
+			return keyDelete, nil
+		}
+	}
+
+	return keyUnknown, nil
+}
+
+// refresh redraws the line and positions the cursor.
+func (e *editor) refresh() {
+	line := string(e.buf)
+	if e.highlight != nil {
+		line = e.highlight(line)
+	}
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", e.prompt, line)
+	if n := len(e.buf) - e.pos; n > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", n)
+	}
+}
+
+// setLine replaces the line moving the cursor to its end.
+func (e *editor) setLine(line []rune) {
+	e.buf = append([]rune{}, line...)
+	e.pos = len(e.buf)
+}
+
+func (e *editor) insert(r rune) {
+	e.buf = append(e.buf, 0)
+	copy(e.buf[e.pos+1:], e.buf[e.pos:])
+	e.buf[e.pos] = r
+	e.pos++
+}
+
+func (e *editor) deleteAt(pos int) {
+	if pos < len(e.buf) {
+		e.buf = append(e.buf[:pos], e.buf[pos+1:]...)
+	}
+}
+
+// search performs the reverse incremental history search
+// started with Ctrl-R. Pressing Ctrl-R again finds an older match.
+// Enter accepts the match and submits it, Ctrl-G or Ctrl-C cancel
+// the search and any other key accepts the match for editing.
+// It reports whether the line should be submitted.
+func (e *editor) search() (bool, error) {
+	orig := e.buf
+	var query []rune
+	match := len(e.history)
+
+	// find looks for the query in history entries starting
+	// at from towards older ones.
+	find := func(from int) {
+		for i := from; i >= 0; i-- {
+			if i < len(e.history) && strings.Contains(e.history[i], string(query)) {
+				match = i
+				return
+			}
+		}
+	}
+
+	for {
+		var found string
+		if match < len(e.history) {
+			found = e.history[match]
+		}
+		fmt.Fprintf(e.out, "\r(reverse-i-search)`%s': %s\x1b[K", string(query), found)
+
+		key, err := e.readKey()
+		if err != nil {
+			return false, err
+		}
+
+		switch key {
+		case ctrlR:
+			find(match - 1)
+		case backspace, ctrlH:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				match = len(e.history)
+				find(match - 1)
+			}
+		case ctrlG, ctrlC:
+			e.setLine(orig)
+			return false, nil
+		case enter, '\n':
+			e.setLine([]rune(found))
+			return true, nil
+		default:
+			if key >= 0 && unicode.IsPrint(key) {
+				query = append(query, key)
+				find(match)
+				continue
+			}
+			e.setLine([]rune(found))
+			return false, nil
+		}
+	}
+}
+
+// completeWord completes the word before the cursor.
+// A single candidate is inserted, multiple candidates are
+// narrowed down to their common prefix and listed if it can't
+// be extended.
+func (e *editor) completeWord() {
+	if e.complete == nil {
+		return
+	}
+
+	start := e.pos
+	for start > 0 && isWordRune(e.buf[start-1]) {
+		start--
+	}
+	prefix := string(e.buf[start:e.pos])
+	if prefix == "" {
+		return
+	}
+
+	var matches []string
+	seen := make(map[string]bool)
+	for _, word := range e.complete() {
+		if strings.HasPrefix(word, prefix) && !seen[word] {
+			seen[word] = true
+			matches = append(matches, word)
+		}
+	}
+	sort.Strings(matches)
+
+	switch len(matches) {
+	case 0:
+		fmt.Fprint(e.out, "\a")
+		return
+	case 1:
+		for _, r := range strings.TrimPrefix(matches[0], prefix) {
+			e.insert(r)
+		}
+		return
+	}
+
+	common := []rune(matches[0])
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(m, string(common)) {
+			common = common[:len(common)-1]
+		}
+	}
+	if n := len([]rune(prefix)); len(common) > n {
+		for _, r := range common[n:] {
+			e.insert(r)
+		}
+		return
+	}
+
+	fmt.Fprint(e.out, "\n"+strings.Join(matches, "  ")+"\n")
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
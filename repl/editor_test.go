@@ -0,0 +1,118 @@
+package repl
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEditorReadLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		keys  string
+		want  string
+		err   error
+		words []string
+	}{
+		{"plain", "let x = 1;\r", "let x = 1;", nil, nil},
+		{"backspace", "1 +x\x7f 2\r", "1 + 2", nil, nil},
+		{"cursor", "13\x1b[D2\x1b[C4\r", "1234", nil, nil},
+		{"home end", "bc\x01a\x05d\r", "abcd", nil, nil},
+		{"home end keys", "bc\x1b[Ha\x1b[Fd\r", "abcd", nil, nil},
+		{"delete", "abc\x01\x1b[3~\x04\r", "c", nil, nil},
+		{"kill", "abc\x02\x0b\r", "ab", nil, nil},
+		{"kill start", "abc\x02\x15\r", "c", nil, nil},
+		{"kill word", "let foo\x17bar\r", "let bar", nil, nil},
+		{"utf-8", "\"héllo\"\x1b[D\x7f\r", "\"héll\"", nil, nil},
+		{"eof", "\x04", "", io.EOF, nil},
+		{"interrupt", "abc\x03", "", errInterrupt, nil},
+		{"complete", "pu\t(1)\r", "push(1)", nil, []string{"push", "let"}},
+		{"complete common", "let counter = co\t\r", "let counter = count", nil, []string{"counter", "count_all", "let"}},
+		{"complete none", "zz\t\r", "zz", nil, []string{"let"}},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		ed := newEditor(strings.NewReader(tt.keys), &out)
+		if tt.words != nil {
+			words := tt.words
+			ed.complete = func() []string { return words }
+		}
+
+		got, err := ed.readLine(PROMPT)
+		if tt.err != err {
+			t.Errorf("%s: expected error %v got %v", tt.name, tt.err, err)
+		}
+		if tt.want != got {
+			t.Errorf("%s: expected line %q got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestEditorCompleteList(t *testing.T) {
+	var out bytes.Buffer
+	ed := newEditor(strings.NewReader("co\t\r"), &out)
+	ed.complete = func() []string { return []string{"count", "continue", "count"} }
+
+	if _, err := ed.readLine(PROMPT); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "\ncontinue  count\n", out.String(); !strings.Contains(got, want) {
+		t.Errorf("Expected output to contain %q got %q", want, got)
+	}
+}
+
+func TestEditorHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "history")
+	if err := ioutil.WriteFile(path, []byte("let a = 1;\nlet b = 2;\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := strings.Join([]string{
+		"\x1b[A\x1b[A\r",      // let a = 1;
+		"\x1b[A\x1b[B\r",      // Back to the empty line.
+		"x\x10\x10\x0e\x0e\r", // Ctrl-P and Ctrl-N restore the edited line.
+		"\x12b = \r",          // Search and submit.
+		"\x12let\x12\x05!\r",  // Search an older match and edit.
+		"\x12a = \x07\r",      // Cancel the search.
+	}, "")
+	ed := newEditor(strings.NewReader(keys), ioutil.Discard)
+	if err := ed.loadHistory(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for {
+		line, err := ed.readLine(PROMPT)
+		if err != nil {
+			break
+		}
+		lines = append(lines, line)
+	}
+
+	want := []string{"let a = 1;", "", "x", "let b = 2;", "let a = 1;!", ""}
+	if diff := cmp.Diff(want, lines); diff != "" {
+		t.Errorf("lines (-want +got)\n%s", diff)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"let a = 1;", "let b = 2;", "let a = 1;", "x", "let b = 2;", "let a = 1;!"}
+	if diff := cmp.Diff(want, strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")); diff != "" {
+		t.Errorf("history file (-want +got)\n%s", diff)
+	}
+}
@@ -0,0 +1,214 @@
+package repl
+
+import (
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pmatseykanets/monkey/eval"
+	"github.com/pmatseykanets/monkey/lexer"
+	"github.com/pmatseykanets/monkey/object"
+	"github.com/pmatseykanets/monkey/token"
+)
+
+// ANSI escape sequences setting the text colour.
+const (
+	colorReset   = "\x1b[0m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorMagenta = "\x1b[35m"
+	colorCyan    = "\x1b[36m"
+	colorGray    = "\x1b[90m"
+)
+
+// Limits of the rendered values.
+const (
+	maxWidth    = 72   // The maximum width of arrays and hashes printed on a single line.
+	maxElements = 100  // The maximum number of array elements or hash pairs printed.
+	maxString   = 1000 // The maximum number of characters of a string printed.
+	maxNesting  = 8    // The maximum nesting of arrays and hashes printed.
+)
+
+// printer renders input and values, optionally in colour.
+type printer struct {
+	color bool
+}
+
+// useColor reports whether the output to w should be coloured:
+// w is a terminal and the NO_COLOR environment variable is not set.
+func useColor(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+
+	return ok && isTerminal(f.Fd())
+}
+
+// paint wraps the s in the color if colouring is enabled.
+func (p printer) paint(color, s string) string {
+	if !p.color || color == "" || s == "" {
+		return s
+	}
+
+	return color + s + colorReset
+}
+
+// tokenColor returns the colour of tokens of the type t
+// or an empty string if they are not coloured.
+func tokenColor(t token.TokenType, literal string) string {
+	switch t {
+	case token.FUNCTION, token.LET, token.IF, token.ELSE, token.RETURN,
+		token.WHILE, token.FOR, token.IN, token.BREAK, token.CONTINUE:
+		return colorMagenta
+	case token.TRUE, token.FALSE, token.INT, token.FLOAT:
+		return colorYellow
+	case token.STRING:
+		return colorGreen
+	case token.ILLEGAL:
+		return colorRed
+	case token.COMMENT:
+		return colorGray
+	case token.IDENT:
+		for _, name := range eval.BuiltinNames() {
+			if name == literal {
+				return colorCyan
+			}
+		}
+	}
+
+	return ""
+}
+
+// highlight colours the tokens of the src.
+func (p printer) highlight(src string) string {
+	if !p.color {
+		return src
+	}
+
+	l := lexer.FromString(src)
+	var toks []token.Token
+	for {
+		tok := l.NextToken()
+		toks = append(toks, tok)
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+	toks = append(toks, l.Comments()...)
+	sort.SliceStable(toks, func(i, j int) bool {
+		return offset(src, toks[i]) < offset(src, toks[j])
+	})
+
+	var buf strings.Builder
+	buf.WriteString(src[:offset(src, toks[0])])
+	for i, tok := range toks[:len(toks)-1] {
+		text := src[offset(src, tok):offset(src, toks[i+1])]
+		trimmed := strings.TrimRightFunc(text, unicode.IsSpace)
+		buf.WriteString(p.paint(tokenColor(tok.Type, tok.Literal), trimmed))
+		buf.WriteString(text[len(trimmed):])
+	}
+
+	return buf.String()
+}
+
+// offset returns the byte offset of the tok in the src.
+func offset(src string, tok token.Token) int {
+	if tok.Type == token.EOF || tok.Pos.Offset > len(src) {
+		return len(src)
+	}
+
+	return tok.Pos.Offset
+}
+
+// value renders the obj. Arrays and hashes that don't fit
+// on a line are printed one element per line.
+// Long strings, arrays, hashes and deep nesting are truncated.
+func (p printer) value(obj object.Object) string {
+	return p.render(obj, 0)
+}
+
+func (p printer) render(obj object.Object, depth int) string {
+	switch obj := obj.(type) {
+	case *object.Integer, *object.Float, *object.Boolean:
+		return p.paint(colorYellow, obj.Inspect())
+	case *object.Null:
+		return p.paint(colorGray, obj.Inspect())
+	case *object.String:
+		s := obj.Value
+		if utf8.RuneCountInString(s) > maxString {
+			s = string([]rune(s)[:maxString])
+			return p.paint(colorGreen, strconv.Quote(s)) + p.paint(colorGray, "...")
+		}
+		return p.paint(colorGreen, strconv.Quote(s))
+	case *object.Error:
+		return p.paint(colorRed, obj.Inspect())
+	case *object.Function, *object.Builtin:
+		return p.paint(colorCyan, obj.Inspect())
+	case *object.Array:
+		if depth >= maxNesting {
+			return p.paint(colorGray, "[...]")
+		}
+		items := make([]string, 0, len(obj.Elements))
+		for i, elem := range obj.Elements {
+			if i == maxElements {
+				items = append(items, p.paint(colorGray, "... "+strconv.Itoa(len(obj.Elements)-i)+" more"))
+				break
+			}
+			items = append(items, p.render(elem, depth+1))
+		}
+		return p.list("[", "]", items, depth)
+	case *object.Hash:
+		if depth >= maxNesting {
+			return p.paint(colorGray, "{...}")
+		}
+		pairs := obj.Ordered()
+		items := make([]string, 0, len(pairs))
+		for i, pair := range pairs {
+			if i == maxElements {
+				items = append(items, p.paint(colorGray, "... "+strconv.Itoa(len(pairs)-i)+" more"))
+				break
+			}
+			items = append(items, p.render(pair.Key, depth+1)+": "+p.render(pair.Value, depth+1))
+		}
+		return p.list("{", "}", items, depth)
+	}
+
+	return obj.Inspect()
+}
+
+// list joins the items on a single line if they fit
+// and one item per line indented by depth otherwise.
+func (p printer) list(open, close string, items []string, depth int) string {
+	line := open + strings.Join(items, ", ") + close
+	if width(line)+2*depth <= maxWidth && !strings.Contains(line, "\n") {
+		return line
+	}
+
+	indent := strings.Repeat("  ", depth+1)
+	return open + "\n" + indent + strings.Join(items, ",\n"+indent) + ",\n" + indent[2:] + close
+}
+
+// width returns the number of characters in the s
+// excluding ANSI escape sequences.
+func width(s string) int {
+	n := 0
+	escape := false
+	for _, r := range s {
+		switch {
+		case escape:
+			escape = r != 'm'
+		case r == '\x1b':
+			escape = true
+		default:
+			n++
+		}
+	}
+
+	return n
+}
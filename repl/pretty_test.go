@@ -0,0 +1,84 @@
+package repl
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pmatseykanets/monkey/eval"
+	"github.com/pmatseykanets/monkey/lexer"
+	"github.com/pmatseykanets/monkey/object"
+	"github.com/pmatseykanets/monkey/parser"
+)
+
+func TestPrinterValue(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`1`, "1"},
+		{`"a\tb"`, `"a\tb"`},
+		{`[1, "a", true]`, `[1, "a", true]`},
+		{`{"b": 2, "a": [1]}`, `{"b": 2, "a": [1]}`},
+		{`[[1, 2], {}]`, `[[1, 2], {}]`},
+		{`let s = "abcdefghij"; [s, s, s, s, s, s, s]`, "[\n" + strings.Repeat("  \"abcdefghij\",\n", 7) + "]"},
+		{`let s = "abcdefghij"; {"k": [s, s, s, s, s, s], "n": 1}`, "{\n" +
+			"  \"k\": [\n" +
+			strings.Repeat("    \"abcdefghij\",\n", 6) +
+			"  ],\n" +
+			"  \"n\": 1,\n" +
+			"}"},
+		{`let a = []; for (i in 103) { a = push(a, 0); } a`, "[\n" + strings.Repeat("  0,\n", 100) + "  ... 3 more,\n]"},
+		{`let a = [1]; for (i in 9) { a = [a]; } a`, "[[[[[[[[[...]]]]]]]]]"},
+	}
+
+	p := printer{}
+	for _, tt := range tests {
+		obj := eval.Eval(parser.New(lexer.FromString(tt.input)).Parse(), object.NewEnvironment())
+		if got := p.value(obj); tt.want != got {
+			t.Errorf("%s: expected\n%s\ngot\n%s", tt.input, tt.want, got)
+		}
+	}
+
+	long := &object.String{Value: strings.Repeat("é", maxString+1)}
+	if want, got := `"`+strings.Repeat("é", maxString)+`"...`, p.value(long); want != got {
+		t.Errorf("Expected truncated string got %s", got)
+	}
+}
+
+func TestPrinterColor(t *testing.T) {
+	p := printer{color: true}
+
+	want := colorYellow + "1" + colorReset
+	if got := p.value(&object.Integer{Value: 1}); want != got {
+		t.Errorf("Expected %q got %q", want, got)
+	}
+
+	want = "[" + colorGreen + `"a"` + colorReset + ", " + colorGray + "null" + colorReset + "]"
+	if got := p.value(&object.Array{Elements: []object.Object{&object.String{Value: "a"}, object.NULL}}); want != got {
+		t.Errorf("Expected %q got %q", want, got)
+	}
+
+	src := `let x = len("a") + 1; // x`
+	want = colorMagenta + "let" + colorReset + " x = " + colorCyan + "len" + colorReset + "(" +
+		colorGreen + `"a"` + colorReset + ") + " + colorYellow + "1" + colorReset + "; " + colorGray + "// x" + colorReset
+	if got := p.highlight(src); want != got {
+		t.Errorf("Expected %q got %q", want, got)
+	}
+
+	if got := (printer{}).highlight(src); src != got {
+		t.Errorf("Expected %q got %q", src, got)
+	}
+}
+
+func TestUseColor(t *testing.T) {
+	if useColor(&strings.Builder{}) {
+		t.Error("Expected no color for a non-terminal writer")
+	}
+
+	defer os.Setenv("NO_COLOR", os.Getenv("NO_COLOR"))
+	os.Setenv("NO_COLOR", "1")
+	if useColor(os.Stdout) {
+		t.Error("Expected no color with NO_COLOR set")
+	}
+}
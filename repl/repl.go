@@ -2,42 +2,328 @@ package repl
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/pmatseykanets/monkey"
+	"github.com/pmatseykanets/monkey/ast"
 	"github.com/pmatseykanets/monkey/eval"
 	"github.com/pmatseykanets/monkey/lexer"
+	"github.com/pmatseykanets/monkey/object"
 	"github.com/pmatseykanets/monkey/parser"
+	"github.com/pmatseykanets/monkey/token"
 )
 
-const PROMPT = ">> "
+const (
+	PROMPT = ">> "
+	// CONTINUATION is the prompt for subsequent lines of incomplete input.
+	CONTINUATION = ".. "
+)
+
+const help = `Commands:
+  \help            show this help
+  \q               quit
+  \tokens <expr>   print the tokens of the expr
+  \ast <expr>      print the syntax tree of the expr
+  \trace on|off    toggle parser tracing
+  \time on|off     toggle reporting evaluation time
+  \env             list bindings
+  \load <file>     evaluate the file
+  \reset           clear all bindings
+`
+
+// session holds the state of a REPL session.
+type session struct {
+	w      io.Writer
+	interp *monkey.Interpreter
+	trace  bool // Whether to trace parsing.
+	timing bool // Whether to report evaluation time.
+	p      printer
+}
+
+// historyFile is the name of the file in the home directory
+// the input history is saved to.
+const historyFile = ".monkey_history"
 
-// Start .
+// Start runs the REPL reading input from r and writing to w.
+// If r is a terminal the input can be edited,
+// searched in the history and completed.
+// If w is a terminal the input and results are coloured
+// unless the NO_COLOR environment variable is set.
 func Start(r io.Reader, w io.Writer) {
-	s := bufio.NewScanner(r)
+	sess := &session{w: w, p: printer{color: useColor(w)}}
+	sess.reset()
+	lines := sess.lineReader(r)
 
+	var input strings.Builder
 	for {
-		fmt.Fprint(w, PROMPT)
-		if !s.Scan() {
-			return
+		prompt := PROMPT
+		if input.Len() > 0 {
+			prompt = CONTINUATION
 		}
-		if s.Text() == "\\q" {
+		line, err := lines.readLine(prompt)
+		if err == errInterrupt {
+			input.Reset()
+			continue
+		}
+		if err != nil {
 			return
 		}
+		if input.Len() == 0 && strings.HasPrefix(line, "\\") {
+			if !sess.command(line) {
+				return
+			}
+			continue
+		}
 
-		p := parser.New(lexer.FromString(s.Text()))
-		prg := p.Parse()
-		if len(p.Errors()) > 0 {
-			fmt.Fprintln(w, "parser errors:")
-			for _, msg := range p.Errors() {
-				fmt.Fprintln(w, "\t"+msg.Error())
+		// An empty line terminates incomplete input
+		// letting the parser report what's missing.
+		if input.Len() == 0 || strings.TrimSpace(line) != "" {
+			input.WriteString(line + "\n")
+			if incomplete(input.String()) {
+				continue
 			}
+		}
+
+		src := input.String()
+		input.Reset()
+		if strings.TrimSpace(src) == "" {
 			continue
 		}
 
-		evald := eval.Eval(prg)
-		if evald != nil {
-			fmt.Fprintln(w, evald.Inspect())
+		sess.eval(src)
+	}
+}
+
+// lineReader reads lines of input.
+type lineReader interface {
+	// readLine shows the prompt and reads a line.
+	readLine(prompt string) (string, error)
+}
+
+// lineReader returns a line editor if r is a terminal
+// and a plain line scanner otherwise.
+func (s *session) lineReader(r io.Reader) lineReader {
+	f, ok := r.(*os.File)
+	if !ok || !isTerminal(f.Fd()) {
+		return &scanner{s: bufio.NewScanner(r), w: s.w}
+	}
+
+	ed := newEditor(f, s.w)
+	ed.complete = s.completions
+	ed.highlight = s.p.highlight
+	if home, err := os.UserHomeDir(); err == nil {
+		if err := ed.loadHistory(filepath.Join(home, historyFile)); err != nil {
+			fmt.Fprintln(s.w, "history:", err)
+		}
+	}
+
+	return &terminal{fd: f.Fd(), ed: ed}
+}
+
+// completions returns keywords, builtins and bound names.
+func (s *session) completions() []string {
+	words := append(token.Keywords(), eval.BuiltinNames()...)
+	return append(words, s.interp.Names()...)
+}
+
+// scanner reads lines with a bufio.Scanner.
+type scanner struct {
+	s *bufio.Scanner
+	w io.Writer
+}
+
+func (s *scanner) readLine(prompt string) (string, error) {
+	fmt.Fprint(s.w, prompt)
+	if !s.s.Scan() {
+		if err := s.s.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+
+	return s.s.Text(), nil
+}
+
+// terminal reads lines with the editor
+// switching the terminal into raw mode for each line.
+type terminal struct {
+	fd uintptr
+	ed *editor
+}
+
+func (t *terminal) readLine(prompt string) (string, error) {
+	restore, err := makeRaw(t.fd)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	return t.ed.readLine(prompt)
+}
+
+// eval parses and evaluates the src printing the result.
+func (s *session) eval(src string) {
+	p := parser.New(lexer.FromString(src))
+	if s.trace {
+		p.WithTrace()
+	}
+	prg := p.Parse()
+	if len(p.Errors()) > 0 {
+		fmt.Fprintln(s.w, "parser errors:")
+		for _, msg := range p.Errors() {
+			fmt.Fprintln(s.w, "\t"+msg.Error())
+		}
+		return
+	}
+
+	start := time.Now()
+	evald, err := s.interp.Eval(context.Background(), prg)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		// Runtime errors are printed as values.
+		evald = err.(*monkey.Error).Runtime
+	}
+	if evald != nil {
+		fmt.Fprintln(s.w, s.p.value(evald))
+	}
+	if s.timing {
+		fmt.Fprintf(s.w, "took %s\n", elapsed)
+	}
+}
+
+// command executes the meta-command in the line.
+// It reports false if the session should end.
+func (s *session) command(line string) bool {
+	name, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		name, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	switch name {
+	case "\\q":
+		return false
+	case "\\help":
+		fmt.Fprint(s.w, help)
+	case "\\tokens":
+		l := lexer.FromString(arg)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+			fmt.Fprintf(s.w, "%s\t%s\t%q\n", tok.Pos, tok.Type, tok.Literal)
+		}
+	case "\\ast":
+		p := parser.New(lexer.FromString(arg))
+		prg := p.Parse()
+		for _, err := range p.Errors() {
+			fmt.Fprintln(s.w, err)
+		}
+		ast.Fprint(s.w, prg)
+	case "\\trace":
+		if !s.toggle(&s.trace, "trace", arg) {
+			return true
+		}
+	case "\\time":
+		if !s.toggle(&s.timing, "time", arg) {
+			return true
 		}
+	case "\\env":
+		for _, name := range s.interp.Names() {
+			val, _ := s.interp.Get(name)
+			if _, ok := val.(*object.Builtin); ok {
+				continue // puts and eputs.
+			}
+			fmt.Fprintf(s.w, "%s = %s\n", name, s.p.value(val))
+		}
+	case "\\load":
+		if arg == "" {
+			fmt.Fprintln(s.w, "usage: \\load <file>")
+			return true
+		}
+		src, err := ioutil.ReadFile(arg)
+		if err != nil {
+			fmt.Fprintln(s.w, err)
+			return true
+		}
+		s.eval(string(src))
+	case "\\reset":
+		s.reset()
+	default:
+		fmt.Fprintf(s.w, "unknown command %s, type \\help for help\n", name)
 	}
+
+	return true
+}
+
+// reset starts the session over with a new interpreter
+// with the same builtins as scripts run by monkey run.
+func (s *session) reset() {
+	s.interp = monkey.New(monkey.WithStdout(s.w))
+}
+
+// toggle sets the flag according to the on or off arg
+// and reports the new state.
+// It reports false if the arg is invalid.
+func (s *session) toggle(flag *bool, name, arg string) bool {
+	switch arg {
+	case "on":
+		*flag = true
+	case "off":
+		*flag = false
+	default:
+		fmt.Fprintf(s.w, "usage: \\%s on|off\n", name)
+		return false
+	}
+
+	fmt.Fprintf(s.w, "%s is %s\n", name, arg)
+	return true
+}
+
+// incomplete reports whether the src needs more input to form
+// complete statements: it has unbalanced braces, parentheses
+// or brackets, ends with an operator or an unterminated string.
+func incomplete(src string) bool {
+	l := lexer.FromString(src)
+
+	var depth int
+	var last token.Token
+	for {
+		tok := l.NextToken()
+		if tok.Type == token.EOF {
+			break
+		}
+
+		switch tok.Type {
+		case token.LBRACE, token.LPAREN, token.LBRACKET:
+			depth++
+		case token.RBRACE, token.RPAREN, token.RBRACKET:
+			depth--
+		case token.ILLEGAL:
+			// The lexer returns the unterminated string
+			// as an illegal token.
+			if tok.Pos.Offset < len(src) && src[tok.Pos.Offset] == '"' {
+				return true
+			}
+		}
+		last = tok
+	}
+
+	if depth > 0 {
+		return true
+	}
+
+	switch last.Type {
+	case token.ASSIGN, token.PLUS, token.MINUS, token.BANG, token.ASTERISK,
+		token.POW, token.SLASH, token.LT, token.GT, token.EQ, token.NOT_EQ,
+		token.PLUS_ASSIGN, token.MINUS_ASSIGN, token.ASTERISK_ASSIGN, token.SLASH_ASSIGN,
+		token.COMMA, token.COLON:
+		return true
+	}
+
+	return false
 }
@@ -0,0 +1,127 @@
+package repl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"", false},
+		{"1 + 2", false},
+		{"let f = fn(x) {", true},
+		{"let f = fn(x) {\nx\n}", false},
+		{"puts(1,", true},
+		{"[1, 2", true},
+		{`{"a": `, true},
+		{"1 +", true},
+		{"let x =", true},
+		{"x **", true},
+		{"!", true},
+		{`"abc`, true},
+		{`"abc\"`, true},
+		{`"abc"`, false},
+		{"}", false},
+		{"@", false},
+	}
+
+	for _, tt := range tests {
+		if got := incomplete(tt.input); tt.want != got {
+			t.Errorf("%q: expected %v got %v", tt.input, tt.want, got)
+		}
+	}
+}
+
+func TestStartMultiLine(t *testing.T) {
+	input := strings.Join([]string{
+		"let add = fn(a, b) {",
+		"  a +",
+		"  b",
+		"};",
+		"add(1,",
+		"2)",
+		`"a`,
+		`b"`,
+		"(1 +",
+		"",
+		`\q`,
+	}, "\n")
+
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	want := ">> .. .. .. " +
+		">> .. 3\n" +
+		">> .. \"a\\nb\"\n" +
+		">> .. parser errors:\n\tmissing prefixFn for EOF\n\texpected token type ) got EOF\n" +
+		">> "
+	if got := out.String(); want != got {
+		t.Errorf("Expected output %q got %q", want, got)
+	}
+}
+
+func TestStartCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	script := filepath.Join(dir, "lib.mk")
+	if err := ioutil.WriteFile(script, []byte("let double = fn(x) { x * 2 };"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hello := filepath.Join(dir, "hello.mk")
+	if err := ioutil.WriteFile(hello, []byte(`puts("hello");`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`\help`, help},
+		{`\tokens let x = "a";`, "1:1\tLET\t\"let\"\n1:5\tIDENT\t\"x\"\n1:7\t=\t\"=\"\n1:9\tSTRING\t\"a\"\n1:12\t;\t\";\"\n"},
+		{`\ast let x = -1 + y;`, "Program\n" +
+			"  Statements[0]: Let\n" +
+			"    Name: Identifier Value=\"x\"\n" +
+			"    Value: Infix Operator=\"+\"\n" +
+			"      Left: Prefix Operator=\"-\"\n" +
+			"        Right: IntegerLiteral Value=1\n" +
+			"      Right: Identifier Value=\"y\"\n"},
+		{`\ast fn(a) { a }`, "Program\n" +
+			"  Statements[0]: BareExpr\n" +
+			"    Value: Function\n" +
+			"      Args[0]: Identifier Value=\"a\"\n" +
+			"      Body: Block\n" +
+			"        Statements[0]: BareExpr\n" +
+			"          Value: Identifier Value=\"a\"\n"},
+		{"let b = 2;\nlet a = [1];\n\\env", "a = [1]\nb = 2\n"},
+		{"let a = 1;\n\\reset\n\\env\na", "ERROR: identifier not found: a\n"},
+		{`\load ` + script + "\ndouble(21)", "42\n"},
+		{`\load ` + hello, "hello\nnull\n"},
+		{"\\reset\nputs(1)", "1\nnull\n"},
+		{`\load ` + filepath.Join(dir, "missing.mk"), "open " + filepath.Join(dir, "missing.mk") + ": no such file or directory\n"},
+		{`\load`, "usage: \\load <file>\n"},
+		{`\trace maybe`, "usage: \\trace on|off\n"},
+		{`\time off`, "time is off\n"},
+		{`\what`, "unknown command \\what, type \\help for help\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		Start(strings.NewReader(tt.input), &out)
+
+		got := strings.Replace(out.String(), PROMPT, "", -1)
+		if tt.want != got {
+			t.Errorf("%q: expected output %q got %q", tt.input, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+package repl
+
+import "errors"
+
+// isTerminal reports whether the file descriptor fd refers to a terminal.
+// Line editing is only supported on Linux so it always reports false.
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+// makeRaw is not supported on this platform.
+func makeRaw(fd uintptr) (func(), error) {
+	return nil, errors.New("raw mode is not supported")
+}
@@ -0,0 +1,233 @@
+// Package resolve implements the static resolution of identifiers.
+//
+// Scopes follow the environments of the evaluator: the program and
+// every function have their own scope and so does the body of each
+// for loop, together with its loop variable. The blocks of if
+// expressions and while loops share the scope they appear in.
+// A let binds the name in its scope no matter where in the scope
+// it appears, as functions may refer to names bound later.
+package resolve
+
+import (
+	"fmt"
+
+	"github.com/pmatseykanets/monkey/ast"
+	"github.com/pmatseykanets/monkey/eval"
+	"github.com/pmatseykanets/monkey/token"
+)
+
+// Kind is the kind of a diagnostic.
+type Kind int
+
+// Kinds of diagnostics.
+const (
+	Undefined          Kind = iota // An identifier is not bound in any scope.
+	DuplicateParameter             // A function has several parameters with the same name.
+	Shadowed                       // A binding shadows the binding of an enclosing scope.
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Undefined:
+		return "undefined"
+	case DuplicateParameter:
+		return "duplicate parameter"
+	case Shadowed:
+		return "shadowed"
+	}
+
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Diagnostic describes a problem found by the resolver.
+type Diagnostic struct {
+	Kind    Kind
+	Pos     token.Position // The position of the identifier.
+	Name    string         // The name of the identifier.
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return d.Pos.String() + ": " + d.Message
+}
+
+// Option configures the resolver.
+type Option func(*resolver)
+
+// WithGlobals declares names bound in the global environment
+// outside of the program, e.g. with Interpreter.Set.
+func WithGlobals(names ...string) Option {
+	return func(r *resolver) {
+		for _, name := range names {
+			r.globals[name] = true
+		}
+	}
+}
+
+// Info holds the results of the resolution.
+type Info struct {
+	// Uses maps identifiers to the identifiers of the bindings they
+	// refer to. The names of let statements, parameters and loop
+	// variables are mapped to the first binding of the name in
+	// their scope, usually themselves. Names not bound by the
+	// program are not mapped.
+	Uses map[*ast.Identifier]*ast.Identifier
+}
+
+// WithInfo records the results of the resolution in the info.
+func WithInfo(info *Info) Option {
+	return func(r *resolver) {
+		if info.Uses == nil {
+			info.Uses = make(map[*ast.Identifier]*ast.Identifier)
+		}
+		r.info = info
+	}
+}
+
+// Resolve resolves the identifiers of the program setting their
+// Depth to the number of scopes between the identifier and the scope
+// binding its name. Names not bound by the program, i.e. builtins
+// and globals, are resolved to the global scope.
+// It returns the diagnostics in the order they are found.
+func Resolve(prg *ast.Program, opts ...Option) []Diagnostic {
+	r := &resolver{globals: make(map[string]bool)}
+	for _, name := range eval.BuiltinNames() {
+		r.globals[name] = true
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	global := r.openScope(nil, declarations(prg.Statements))
+	for _, stmt := range prg.Statements {
+		r.resolve(global, stmt)
+	}
+
+	return r.diags
+}
+
+type resolver struct {
+	globals map[string]bool
+	info    *Info
+	diags   []Diagnostic
+}
+
+// scope holds the names bound in a scope.
+type scope struct {
+	outer *scope
+	names map[string]int    // The indexes of the names in decls.
+	decls []*ast.Identifier // The first binding of each name.
+}
+
+func (r *resolver) report(kind Kind, ident *ast.Identifier, format string, a ...interface{}) {
+	r.diags = append(r.diags, Diagnostic{
+		Kind:    kind,
+		Pos:     ident.Token.Pos,
+		Name:    ident.Value,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+// openScope creates a scope nested in the outer one binding the names
+// of the decls, reporting those shadowing bindings of enclosing scopes.
+func (r *resolver) openScope(outer *scope, decls []*ast.Identifier) *scope {
+	s := &scope{outer: outer, names: make(map[string]int)}
+	for _, ident := range decls {
+		if _, ok := s.names[ident.Value]; ok {
+			continue
+		}
+		s.names[ident.Value] = len(s.decls)
+		s.decls = append(s.decls, ident)
+
+		for o := outer; o != nil; o = o.outer {
+			if i, ok := o.names[ident.Value]; ok {
+				r.report(Shadowed, ident, "%s shadows the binding at %s", ident.Value, o.decls[i].Token.Pos)
+				break
+			}
+		}
+	}
+
+	return s
+}
+
+// resolve resolves the identifiers in the node found in the scope s.
+func (r *resolver) resolve(s *scope, node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Identifier:
+			r.resolveIdentifier(s, n)
+		case *ast.Function:
+			seen := make(map[string]bool)
+			for _, arg := range n.Args {
+				if seen[arg.Value] {
+					r.report(DuplicateParameter, arg, "duplicate parameter %s", arg.Value)
+				}
+				seen[arg.Value] = true
+			}
+
+			decls := append(append([]*ast.Identifier{}, n.Args...), declarations(n.Body.Statements)...)
+			fs := r.openScope(s, decls)
+			for _, arg := range n.Args {
+				r.resolveIdentifier(fs, arg)
+			}
+			r.resolve(fs, n.Body)
+			return false
+		case *ast.For:
+			r.resolve(s, n.Iterable)
+
+			decls := append([]*ast.Identifier{n.Var}, declarations(n.Body.Statements)...)
+			ls := r.openScope(s, decls)
+			r.resolveIdentifier(ls, n.Var)
+			r.resolve(ls, n.Body)
+			return false
+		}
+		return true
+	})
+}
+
+func (r *resolver) resolveIdentifier(s *scope, ident *ast.Identifier) {
+	depth := 0
+	for ; s != nil; s = s.outer {
+		if i, ok := s.names[ident.Value]; ok {
+			ident.Depth = depth
+			if r.info != nil {
+				r.info.Uses[ident] = s.decls[i]
+			}
+			return
+		}
+		if s.outer != nil {
+			depth++
+		}
+	}
+
+	ident.Depth = depth
+	if !r.globals[ident.Value] {
+		r.report(Undefined, ident, "undefined: %s", ident.Value)
+	}
+}
+
+// declarations returns the names bound by let statements in the list
+// and the blocks of if expressions and while loops in it
+// in the order they appear.
+func declarations(list []ast.Statement) []*ast.Identifier {
+	var decls []*ast.Identifier
+	var collect func(ast.Node) bool
+	collect = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Let:
+			decls = append(decls, n.Name)
+		case *ast.Function:
+			return false
+		case *ast.For:
+			// The body of the loop has its own scope.
+			ast.Inspect(n.Iterable, collect)
+			return false
+		}
+		return true
+	}
+	for _, stmt := range list {
+		ast.Inspect(stmt, collect)
+	}
+
+	return decls
+}
@@ -0,0 +1,177 @@
+package resolve
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pmatseykanets/monkey/ast"
+	"github.com/pmatseykanets/monkey/eval"
+	"github.com/pmatseykanets/monkey/lexer"
+	"github.com/pmatseykanets/monkey/object"
+	"github.com/pmatseykanets/monkey/parser"
+	"github.com/pmatseykanets/monkey/token"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.FromString(input))
+	prg := p.Parse()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("%q: parse errors %v", input, errs)
+	}
+
+	return prg
+}
+
+func TestResolveDiagnostics(t *testing.T) {
+	tests := []struct {
+		input string
+		opts  []Option
+		want  []string
+	}{
+		{"let x = 1; x + len([])", nil, nil},
+		{"let f = fn() { g() }; let g = fn() { 1 };", nil, nil},
+		{"let f = fn(n) { if (n > 0) { f(n - 1) } }", nil, nil},
+		{"if (true) { let x = 1; }; x", nil, nil},
+		{"while (false) { let y = 1; } y", nil, nil},
+		{"let s = 0; for (i in 3) { s += i; }", nil, nil},
+		{"lenght([])", nil, []string{"1:1: undefined: lenght"}},
+		{"let f = fn() { if (false) { typo } }", nil, []string{"1:29: undefined: typo"}},
+		{"x = 1", nil, []string{"1:1: undefined: x"}},
+		{"for (i in 3) { let sq = i * i; } sq", nil, []string{"1:34: undefined: sq"}},
+		{"for (i in 3) { } i", nil, []string{"1:18: undefined: i"}},
+		{"args[0]", []Option{WithGlobals("args")}, nil},
+		{
+			"fn(a, b, a) { a }",
+			nil,
+			[]string{"1:10: duplicate parameter a"},
+		},
+		{
+			"let x = 1; let f = fn(x) { let y = 2; fn() { let y = 3; x + y } }",
+			nil,
+			[]string{"1:23: x shadows the binding at 1:5", "1:50: y shadows the binding at 1:32"},
+		},
+		{
+			"let i = 0; for (i in 3) { let i = 1; }",
+			nil,
+			[]string{"1:17: i shadows the binding at 1:5"},
+		},
+		{"let x = 1; let x = 2; if (true) { let x = 3; }", nil, nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, d := range Resolve(parse(t, tt.input), tt.opts...) {
+			got = append(got, d.String())
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%q: unexpected diagnostics (-want +got):\n%s", tt.input, diff)
+		}
+	}
+}
+
+func TestResolveDiagnosticKinds(t *testing.T) {
+	prg := parse(t, "let a = 1; fn(a, a) { b }")
+
+	want := []Diagnostic{
+		{Kind: DuplicateParameter, Pos: token.Position{Offset: 17, Line: 1, Column: 18}, Name: "a", Message: "duplicate parameter a"},
+		{Kind: Shadowed, Pos: token.Position{Offset: 14, Line: 1, Column: 15}, Name: "a", Message: "a shadows the binding at 1:5"},
+		{Kind: Undefined, Pos: token.Position{Offset: 22, Line: 1, Column: 23}, Name: "b", Message: "undefined: b"},
+	}
+	if diff := cmp.Diff(want, Resolve(prg)); diff != "" {
+		t.Errorf("Unexpected diagnostics (-want +got):\n%s", diff)
+	}
+}
+
+func TestResolveAnnotations(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string // Depth of the identifiers in the order they are visited.
+	}{
+		{"let a = 1; let b = a;", []string{"a 0", "b 0", "a 0"}},
+		{
+			"let a = 1; let f = fn(x) { let y = x; fn() { a + x + y } }",
+			[]string{"a 0", "f 0", "x 0", "y 0", "x 0", "a 2", "x 1", "y 1"},
+		},
+		{
+			"let s = 0; for (i in [s]) { let t = i; if (true) { let u = s; } }",
+			[]string{"s 0", "i 0", "s 0", "t 0", "i 0", "u 0", "s 1"},
+		},
+		{"fn() { puts(len) }", []string{"puts 1", "len 1"}},
+		{"puts", []string{"puts 0"}},
+	}
+
+	for _, tt := range tests {
+		prg := parse(t, tt.input)
+		Resolve(prg)
+
+		var got []string
+		ast.Inspect(prg, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Identifier); ok {
+				got = append(got, fmt.Sprintf("%s %d", ident.Value, ident.Depth))
+			}
+			return true
+		})
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%q: unexpected annotations (-want +got):\n%s", tt.input, diff)
+		}
+	}
+}
+
+func TestResolvedEval(t *testing.T) {
+	tests := []string{
+		"let a = 1; let f = fn(x) { let y = x; fn() { a + x + y } }; f(2)()",
+		"let x = 1; let f = fn(c) { if (c) { let x = 2; }; x }; [f(true), f(false)]",
+		"let s = 0; for (i in 5) { let t = i * 2; s += t; } s",
+		"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(10)",
+		"let counter = fn() { let n = 0; fn() { n += 1; n } }; let c = counter(); c(); c()",
+		"let x = 5; for (x in [1, 2]) { x } x",
+		"undefined_name",
+	}
+
+	for _, input := range tests {
+		want := eval.Eval(parse(t, input), object.NewEnvironment())
+
+		prg := parse(t, input)
+		Resolve(prg)
+		got := eval.Eval(prg, object.NewEnvironment())
+
+		if want.Inspect() != got.Inspect() {
+			t.Errorf("%q: expected %s got %s", input, want.Inspect(), got.Inspect())
+		}
+	}
+}
+
+func TestResolveInfo(t *testing.T) {
+	prg := parse(t, "let x = 1; let f = fn(x) { x + y }; let x = 2; x")
+
+	var info Info
+	Resolve(prg, WithInfo(&info))
+
+	var got []string
+	ast.Inspect(prg, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Identifier); ok {
+			if binding, ok := info.Uses[ident]; ok {
+				got = append(got, fmt.Sprintf("%s %s -> %s", ident.Value, ident.Token.Pos, binding.Token.Pos))
+			} else {
+				got = append(got, fmt.Sprintf("%s %s unbound", ident.Value, ident.Token.Pos))
+			}
+		}
+		return true
+	})
+
+	want := []string{
+		"x 1:5 -> 1:5",
+		"f 1:16 -> 1:16",
+		"x 1:23 -> 1:23",
+		"x 1:28 -> 1:23",
+		"y 1:32 unbound",
+		"x 1:41 -> 1:5",
+		"x 1:48 -> 1:5",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected uses (-want +got):\n%s", diff)
+	}
+}